@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/gojira/ferry/config"
+	"github.com/gojira/ferry/oauth"
+)
+
+var noBrowser bool
+
+func init() {
+	rootCmd.AddCommand(authCmd)
+	authCmd.AddCommand(loginCmd)
+
+	fl := loginCmd.Flags()
+
+	fl.StringVarP(&configFile, "config", "c", "config.json", "Path to config in json format. default=config.json")
+	fl.BoolVar(&noBrowser, "no-browser", false, "Only print the authorization URL instead of opening the browser")
+}
+
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Manage the authentication to JIRA",
+}
+
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Authorize ferry with OAuth 2.0 and store the refresh token",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		err, c := config.New(configFile)
+		if err != nil {
+			return err
+		}
+
+		if c.Auth.Mode != config.AuthOAuth {
+			return errors.New("auth login requires \"Auth\": {\"Mode\": \"oauth\"} in the config")
+		}
+
+		o := oauth.FromConfig(c)
+		err, token := oauth.Login(o, openBrowser)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(" Authorized for " + token.SiteURL + ". Token stored in " + "'" + o.TokenFile + "'")
+		return nil
+	},
+}
+
+// openBrowser prints the authorization URL and tries to open it in the default browser
+func openBrowser(url string) error {
+	fmt.Println(" Open the following URL to authorize ferry:\n\n " + url + "\n")
+	if noBrowser {
+		return nil
+	}
+
+	var open *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		open = exec.Command("open", url)
+	case "windows":
+		open = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		open = exec.Command("xdg-open", url)
+	}
+
+	// the URL is printed anyway, failing to start a browser is not an error
+	open.Start()
+	return nil
+}
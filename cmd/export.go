@@ -9,11 +9,25 @@ import (
 )
 
 var (
-	jiraUrl     string
-	projectName string
-	sprintName  string
-	outputFile  string
-	configFile  string
+	jiraUrl      string
+	projectName  string
+	sprintName   string
+	outputFile   string
+	configFile   string
+	format       string
+	sheetPerType bool
+	concurrency  int
+	maxAttempts  int
+	retryTimeout string
+	rateLimit    float64
+	jql          string
+	jqlMode      string
+	orderBy      string
+	filterID     int
+	boardName    string
+	separator    string
+	explode      []string
+	mode         string
 )
 
 func init() {
@@ -23,9 +37,23 @@ func init() {
 
 	fl.StringVarP(&configFile, "config", "c", "config.json", "Path to config in json format. default=config.json")
 	fl.StringVarP(&outputFile, "output", "o", "", "The target file where output will be exported to")
+	fl.StringVarP(&format, "format", "f", "", "Output format: csv, tsv, json, ndjson, xlsx, or the xes and ocel event logs, overwrite config.Format. Inferred from --output extension when empty")
+	fl.BoolVar(&sheetPerType, "sheet-per-issuetype", false, "Write one sheet per issue type, xlsx output only. overwrite config.SheetPerIssueType")
+	fl.IntVar(&concurrency, "concurrency", 0, "Maximum number of requests sent to JIRA at the same time, overwrite config.Concurrency. default=10")
+	fl.IntVar(&maxAttempts, "max-attempts", 0, "Maximum number of attempts per request when JIRA fails temporarily, overwrite config.Retry.MaxAttempts. default=4")
+	fl.StringVar(&retryTimeout, "retry-deadline", "", "Maximum time spent retrying one request, such as 2m, overwrite config.Retry.Deadline")
+	fl.Float64Var(&rateLimit, "rate-limit", 0, "Maximum number of requests per second sent to JIRA, overwrite config.RateLimit.RequestsPerSecond")
+	fl.StringVar(&separator, "separator", "", "Separator of the values of multi-value fields, overwrite config.MultiValueSeparator. default=\", \"")
+	fl.StringSliceVar(&explode, "explode", nil, "Multi-value fields written with one row per value, overwrite config.Explode")
+	fl.StringVar(&mode, "mode", "", "What is exported: issues, one row per issue, or transitions, one row per change of their changelog. overwrite config.Mode. default=issues")
 	fl.StringVar(&jiraUrl, "jira.url", "", "URL to JIRA worskspace, overwrite config.JiraUrl")
 	fl.StringVar(&projectName, "project", "", "The project to grab issues from, overwrite config.Filters.Project")
-	fl.StringVar(&sprintName, "sprint", "", "Name of the sprint to export, overwrite config.Filters.Sprint")
+	fl.StringVar(&jql, "jql", "", "Raw JQL query, custom field names resolve to their cf[id], overwrite config.Jql")
+	fl.StringVar(&jqlMode, "jql-mode", "", "How --jql combines with config.Filters: and or replace, overwrite config.JqlMode. default=and")
+	fl.StringVar(&orderBy, "order-by", "", "Order of the exported issues, such as \"rank ASC\" or \"created DESC\", overwrite config.OrderBy")
+	fl.IntVar(&filterID, "filter-id", 0, "Id of a filter saved in JIRA, its JQL is used like --jql. overwrite config.FilterId")
+	fl.StringVar(&boardName, "board", "", "Name or id of the board whose sprint is exported, overwrite config.Board")
+	fl.StringVar(&sprintName, "sprint", "", "Name of the sprint to export, or active and next with --board, overwrite config.Filters.Sprint")
 }
 
 var exportCmd = &cobra.Command{
@@ -40,12 +68,76 @@ var exportCmd = &cobra.Command{
 		//overwrite config
 		if outputFile != "" {
 			c.DownloadPath = outputFile
+
+			if f := jirafinder.FormatFromPath(outputFile); f != "" && format == "" {
+				c.Format = f
+			}
+		}
+
+		if format != "" {
+			c.Format = format
+		}
+
+		if sheetPerType {
+			c.SheetPerIssueType = true
+		}
+
+		if concurrency > 0 {
+			c.Concurrency = concurrency
+		}
+
+		if maxAttempts > 0 {
+			c.Retry.MaxAttempts = maxAttempts
+		}
+
+		if retryTimeout != "" {
+			c.Retry.Deadline = retryTimeout
+		}
+
+		if rateLimit > 0 {
+			c.RateLimit.RequestsPerSecond = rateLimit
+		}
+
+		if separator != "" {
+			c.MultiValueSeparator = separator
+		}
+
+		if len(explode) > 0 {
+			c.Explode = explode
+		}
+
+		if mode != "" {
+			c.Mode = mode
 		}
 
 		if jiraUrl != "" {
 			c.JiraURL = jiraUrl
 		}
 
+		if jql != "" {
+			c.Jql = jql
+		}
+
+		if jqlMode != "" {
+			c.JqlMode = jqlMode
+		}
+
+		if orderBy != "" {
+			c.OrderBy = orderBy
+		}
+
+		if filterID > 0 {
+			c.FilterID = filterID
+		}
+
+		if boardName != "" {
+			c.Board = boardName
+		}
+
+		if c.Filters == nil {
+			c.Filters = make(map[string]interface{})
+		}
+
 		if projectName != "" {
 			c.Filters["Project"] = projectName
 		}
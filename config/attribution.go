@@ -0,0 +1,13 @@
+package config
+
+// AttributionRule decides who developed an issue from the transitions of its changelog
+type AttributionRule struct {
+	// Name is recorded in the attribution column when the rule matches, rule N by default
+	Name string `json:"Name"`
+	// IssueTypes are the issue types the rule applies to, matched ignoring case, all of them when empty
+	IssueTypes []string `json:"IssueTypes"`
+	// Statuses are the statuses whose transitions attribute the issue
+	Statuses []string `json:"Statuses"`
+	// Strategy is one of first-author, last-author, assignee or most-frequent-assignee, first-author when empty
+	Strategy string `json:"Strategy"`
+}
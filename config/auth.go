@@ -0,0 +1,93 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Authentication modes
+const (
+	AuthBasic    = "basic"
+	AuthAPIToken = "api-token"
+	AuthBearer   = "bearer"
+	AuthOAuth    = "oauth"
+)
+
+// Auth selects how ferry authenticates against jira
+type Auth struct {
+	// Mode is one of basic, api-token, bearer or oauth, basic when empty
+	Mode string `json:"Mode"`
+	// Username and Password are used by the basic mode, they default to the Credentials block
+	Username string `json:"Username"`
+	Password string `json:"Password"`
+	// Email and Token are used by the api-token mode (Jira Cloud)
+	Email string `json:"Email"`
+	// Token is the API token of the api-token mode or the Personal Access Token of the bearer mode
+	Token string `json:"Token"`
+	// OAuth configures the oauth mode
+	OAuth OAuth `json:"OAuth"`
+}
+
+// OAuth configures the OAuth 2.0 (3LO) flow of Atlassian Cloud, the endpoints
+// default to the Atlassian ones
+type OAuth struct {
+	ClientID     string   `json:"ClientId"`
+	ClientSecret string   `json:"ClientSecret"`
+	Scopes       []string `json:"Scopes"`
+	// RedirectURL is the loopback callback registered for the app, http://localhost:8085/callback by default
+	RedirectURL string `json:"RedirectUrl"`
+	// TokenFile stores the refresh token obtained by 'ferry auth login'
+	TokenFile    string `json:"TokenFile"`
+	AuthURL      string `json:"AuthUrl"`
+	TokenURL     string `json:"TokenUrl"`
+	ResourcesURL string `json:"ResourcesUrl"`
+	APIURL       string `json:"ApiUrl"`
+}
+
+// setAuthToken validates the auth section and computes the scheme and token of the Authorization header
+func (c *Configuration) setAuthToken() error {
+	a := &c.Auth
+	a.Mode = strings.ToLower(strings.TrimSpace(a.Mode))
+
+	switch a.Mode {
+	case "", AuthBasic:
+		a.Mode = AuthBasic
+
+		c.AuthScheme = "Basic"
+		c.AuthToken = encodeStringToBase64(a.Username + ":" + a.Password)
+
+	case AuthAPIToken:
+		if a.Email == "" {
+			a.Email = a.Username
+		}
+		if a.Email == "" || a.Token == "" {
+			return errors.New("Auth.Email and Auth.Token are required by the api-token mode")
+		}
+
+		c.AuthScheme = "Basic"
+		c.AuthToken = encodeStringToBase64(a.Email + ":" + a.Token)
+
+	case AuthBearer:
+		if a.Token == "" {
+			return errors.New("Auth.Token is required by the bearer mode")
+		}
+
+		c.AuthScheme = "Bearer"
+		c.AuthToken = a.Token
+
+	case AuthOAuth:
+		if a.OAuth.ClientID == "" || a.OAuth.ClientSecret == "" {
+			return errors.New("Auth.OAuth.ClientId and Auth.OAuth.ClientSecret are required by the oauth mode")
+		}
+
+		// the access token is obtained at runtime from the token file
+		c.AuthScheme = "Bearer"
+		c.AuthToken = ""
+
+	default:
+		return errors.Errorf("unknown Auth.Mode '%s', expected basic, api-token, bearer or oauth", a.Mode)
+	}
+
+	return nil
+}
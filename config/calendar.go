@@ -0,0 +1,16 @@
+package config
+
+// Calendar defines the working time of the team, the durations computed by
+// ferry then count the working hours only
+type Calendar struct {
+	// WorkingDays are the names of the days worked, monday to friday by default
+	WorkingDays []string `json:"WorkingDays"`
+	// WorkingHours are the start and end of the working day such as "09:00-17:00", the whole day when empty
+	WorkingHours string `json:"WorkingHours"`
+	// Timezone is the IANA name of the timezone of the working hours, UTC by default
+	Timezone string `json:"Timezone"`
+	// Holidays are the dates not worked, such as 2020-12-25
+	Holidays []string `json:"Holidays"`
+	// HolidaysFile is an iCalendar file whose events are days not worked
+	HolidaysFile string `json:"HolidaysFile"`
+}
@@ -11,12 +11,49 @@ import (
 )
 
 type Configuration struct {
-	JiraURL          string                 `json:"JiraUrl"`
-	Credentials      Credentials            `json:"Credentials"`
-	Filters          map[string]interface{} `json:"Filters"`
-	FieldsToRetrieve []string               `json:"FieldsToRetrieve"`
-	DownloadPath     string                 `json:"DownloadPath"`
-	AuthToken        string
+	JiraURL             string                 `json:"JiraUrl"`
+	Credentials         Credentials            `json:"Credentials"`
+	Auth                Auth                   `json:"Auth"`
+	CredentialsFile     string                 `json:"CredentialsFile"`
+	CredentialCommand   string                 `json:"CredentialCommand"`
+	Filters             map[string]interface{} `json:"Filters"`
+	Jql                 string                 `json:"Jql"`
+	JqlMode             string                 `json:"JqlMode"`
+	OrderBy             string                 `json:"OrderBy"`
+	FilterID            int                    `json:"FilterId"`
+	Board               string                 `json:"Board"`
+	FieldsToRetrieve    []Field                `json:"FieldsToRetrieve"`
+	MultiValueSeparator string                 `json:"MultiValueSeparator"`
+	Explode             []string               `json:"Explode"`
+	DerivedFields       []DerivedField         `json:"DerivedFields"`
+	Attribution         []AttributionRule      `json:"Attribution"`
+	Flow                Flow                   `json:"Flow"`
+	Calendar            *Calendar              `json:"Calendar"`
+	Mode                string                 `json:"Mode"`
+	DownloadPath        string                 `json:"DownloadPath"`
+	Format              string                 `json:"Format"`
+	SheetPerIssueType   bool                   `json:"SheetPerIssueType"`
+	Concurrency         int                    `json:"Concurrency"`
+	Retry               Retry                  `json:"Retry"`
+	RateLimit           RateLimit              `json:"RateLimit"`
+	AuthScheme          string
+	AuthToken           string
+}
+
+// Retry configures how failed requests to jira are retried
+type Retry struct {
+	// MaxAttempts is the number of attempts per request, including the first one
+	MaxAttempts int `json:"MaxAttempts"`
+	// Deadline bounds the time spent on one request and its retries, as a duration such as "2m"
+	Deadline string `json:"Deadline"`
+}
+
+// RateLimit caps the rate of requests sent to jira
+type RateLimit struct {
+	// RequestsPerSecond is the average rate allowed, zero disables the limit
+	RequestsPerSecond float64 `json:"RequestsPerSecond"`
+	// Burst is the number of requests allowed at once, 1 when not set
+	Burst int `json:"Burst"`
 }
 
 type Credentials struct {
@@ -75,7 +112,22 @@ func New(confgFile string) (error, *Configuration) {
 		return errors.Wrapf(err, "failed to parse config file"), nil
 	}
 
-	c.AuthToken = encodeStringToBase64(c.Credentials.Username + ":" + c.Credentials.Password)
+	if err := c.resolveCredentials(filepath.Dir(confgFile)); err != nil {
+		return errors.Wrapf(err, "failed to resolve credentials"), nil
+	}
+
+	// the holidays and token files are relative to the config, like the credentials file
+	if c.Calendar != nil && c.Calendar.HolidaysFile != "" {
+		c.Calendar.HolidaysFile = resolvePath(filepath.Dir(confgFile), c.Calendar.HolidaysFile)
+	}
+
+	if c.Auth.OAuth.TokenFile != "" {
+		c.Auth.OAuth.TokenFile = resolvePath(filepath.Dir(confgFile), c.Auth.OAuth.TokenFile)
+	}
+
+	if err := c.setAuthToken(); err != nil {
+		return errors.Wrapf(err, "invalid config file"), nil
+	}
 
 	return nil, c
 }
@@ -1,7 +1,11 @@
 package config
 
 import (
+	"encoding/base64"
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -28,4 +32,111 @@ func TestJiraFinder_CreateConfigSuccess(t *testing.T) {
 	r.NoErrorf(err, "expected reading config succeed, got error: '%s'", err)
 	r.NotNil(c, "expected to have an healthy config, got nil")
 	r.NotEmpty(c.AuthToken, ".AuthToken should not be empty")
-}
\ No newline at end of file
+}
+
+func writeConfig(t *testing.T, content string) string {
+	file, err := ioutil.TempFile("", "ferry-config-*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	if _, err := file.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+
+	return file.Name()
+}
+
+func TestJiraFinder_CreateConfigBasicAuthFromCredentials(t *testing.T) {
+	r := assert.New(t)
+	path := writeConfig(t, `{"JiraUrl": "https://jira", "Credentials": {"Username": "user", "Password": "secret"}}`)
+	defer os.Remove(path)
+
+	err, c := New(path)
+	r.NoError(err)
+	r.Equal(AuthBasic, c.Auth.Mode)
+	r.Equal("Basic", c.AuthScheme)
+	r.Equal(base64.StdEncoding.EncodeToString([]byte("user:secret")), c.AuthToken)
+}
+
+func TestJiraFinder_CreateConfigAPITokenAuth(t *testing.T) {
+	r := assert.New(t)
+	path := writeConfig(t, `{"JiraUrl": "https://jira", "Auth": {"Mode": "api-token", "Email": "me@example.com", "Token": "abc"}}`)
+	defer os.Remove(path)
+
+	err, c := New(path)
+	r.NoError(err)
+	r.Equal("Basic", c.AuthScheme)
+	r.Equal(base64.StdEncoding.EncodeToString([]byte("me@example.com:abc")), c.AuthToken)
+}
+
+func TestJiraFinder_CreateConfigBearerAuth(t *testing.T) {
+	r := assert.New(t)
+	path := writeConfig(t, `{"JiraUrl": "https://jira", "Auth": {"Mode": "Bearer", "Token": "pat"}}`)
+	defer os.Remove(path)
+
+	err, c := New(path)
+	r.NoError(err)
+	r.Equal("Bearer", c.AuthScheme)
+	r.Equal("pat", c.AuthToken)
+}
+
+func TestJiraFinder_CreateConfigInvalidAuth(t *testing.T) {
+	r := assert.New(t)
+
+	path := writeConfig(t, `{"JiraUrl": "https://jira", "Auth": {"Mode": "bearer"}}`)
+	defer os.Remove(path)
+	err, _ := New(path)
+	r.Error(err)
+	r.Contains(err.Error(), "Auth.Token is required by the bearer mode")
+
+	other := writeConfig(t, `{"JiraUrl": "https://jira", "Auth": {"Mode": "kerberos"}}`)
+	defer os.Remove(other)
+	err, _ = New(other)
+	r.Error(err)
+	r.Contains(err.Error(), "unknown Auth.Mode 'kerberos'")
+}
+
+func TestJiraFinder_CreateConfigFieldsToRetrieve(t *testing.T) {
+	r := assert.New(t)
+	path := writeConfig(t, `{"JiraUrl": "https://jira", "FieldsToRetrieve": ["key", {"Field": "customfield_10016", "Header": "Story Points", "Format": "number"}]}`)
+	defer os.Remove(path)
+
+	err, c := New(path)
+	r.NoError(err)
+	r.Equal([]Field{{Field: "key"}, {Field: "customfield_10016", Header: "Story Points", Format: "number"}}, c.FieldsToRetrieve)
+	r.Equal([]string{"key", "customfield_10016"}, c.FieldNames())
+	r.Equal([]string{"key", "Story Points"}, c.Headers())
+}
+
+func TestJiraFinder_CreateConfigFieldWithoutName(t *testing.T) {
+	r := assert.New(t)
+	path := writeConfig(t, `{"JiraUrl": "https://jira", "FieldsToRetrieve": [{"Header": "Story Points"}]}`)
+	defer os.Remove(path)
+
+	err, _ := New(path)
+	r.Error(err)
+	r.Contains(err.Error(), "has no Field")
+}
+
+func TestJiraFinder_CreateConfigHolidaysFile(t *testing.T) {
+	r := assert.New(t)
+	path := writeConfig(t, `{"JiraUrl": "https://jira", "Calendar": {"HolidaysFile": "holidays.ics", "Holidays": ["2020-12-25"]}}`)
+	defer os.Remove(path)
+
+	err, c := New(path)
+	r.NoError(err)
+	r.Equal(filepath.Join(filepath.Dir(path), "holidays.ics"), c.Calendar.HolidaysFile)
+	r.Equal([]string{"2020-12-25"}, c.Calendar.Holidays)
+}
+
+func TestJiraFinder_CreateConfigTokenFile(t *testing.T) {
+	r := assert.New(t)
+	path := writeConfig(t, `{"JiraUrl": "https://jira", "Auth": {"Mode": "oauth", "OAuth": {"ClientID": "id", "ClientSecret": "secret", "TokenFile": "tokens/ferry.json"}}}`)
+	defer os.Remove(path)
+
+	err, c := New(path)
+	r.NoError(err)
+	r.Equal(filepath.Join(filepath.Dir(path), "tokens", "ferry.json"), c.Auth.OAuth.TokenFile)
+}
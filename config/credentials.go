@@ -0,0 +1,185 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Environment variables holding the credentials
+const (
+	EnvUsername     = "FERRY_USERNAME"
+	EnvPassword     = "FERRY_PASSWORD"
+	EnvEmail        = "FERRY_EMAIL"
+	EnvToken        = "FERRY_TOKEN"
+	EnvClientSecret = "FERRY_CLIENT_SECRET"
+)
+
+// secrets are the credentials found outside of the config file
+type secrets struct {
+	Username     string `json:"Username"`
+	Password     string `json:"Password"`
+	Email        string `json:"Email"`
+	Token        string `json:"Token"`
+	ClientSecret string `json:"ClientSecret"`
+}
+
+// merge fills the empty values of s with the ones of other
+func (s *secrets) merge(other secrets) {
+	fill := func(dst *string, val string) {
+		if *dst == "" {
+			*dst = val
+		}
+	}
+
+	fill(&s.Username, other.Username)
+	fill(&s.Password, other.Password)
+	fill(&s.Email, other.Email)
+	fill(&s.Token, other.Token)
+	fill(&s.ClientSecret, other.ClientSecret)
+}
+
+// secret gives the pointer to the secret used by the auth mode
+func (s *secrets) secret(mode string) *string {
+	switch mode {
+	case AuthAPIToken, AuthBearer:
+		return &s.Token
+	case AuthOAuth:
+		return &s.ClientSecret
+	}
+
+	return &s.Password
+}
+
+// resolveCredentials looks up the credentials in the environment, then in
+// the credentials file and finally runs the credential command when the
+// secret is still missing. Values found this way take precedence over the
+// ones written in the config file.
+func (c *Configuration) resolveCredentials(configDir string) error {
+	mode := strings.ToLower(strings.TrimSpace(c.Auth.Mode))
+	if mode == "" {
+		mode = AuthBasic
+	}
+
+	found := secrets{
+		Username:     os.Getenv(EnvUsername),
+		Password:     os.Getenv(EnvPassword),
+		Email:        os.Getenv(EnvEmail),
+		Token:        os.Getenv(EnvToken),
+		ClientSecret: os.Getenv(EnvClientSecret),
+	}
+
+	if c.CredentialsFile != "" {
+		err, fromFile := readCredentialsFile(resolvePath(configDir, c.CredentialsFile))
+		if err != nil {
+			return err
+		}
+		found.merge(fromFile)
+	}
+
+	if secret := found.secret(mode); *secret == "" && c.CredentialCommand != "" {
+		err, out := runCredentialCommand(c.CredentialCommand)
+		if err != nil {
+			return err
+		}
+		*secret = out
+	}
+
+	// the Credentials block only backs the basic mode, move it to Auth so
+	// that a partial override keeps the other value
+	if mode == AuthBasic {
+		if c.Auth.Username == "" {
+			c.Auth.Username = c.Credentials.Username
+		}
+		if c.Auth.Password == "" {
+			c.Auth.Password = c.Credentials.Password
+		}
+	}
+
+	override := func(dst *string, val string) {
+		if val != "" {
+			*dst = val
+		}
+	}
+
+	override(&c.Auth.Username, found.Username)
+	override(&c.Auth.Password, found.Password)
+	override(&c.Auth.Email, found.Email)
+	override(&c.Auth.Token, found.Token)
+	override(&c.Auth.OAuth.ClientSecret, found.ClientSecret)
+
+	return nil
+}
+
+func resolvePath(dir string, path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(dir, path)
+}
+
+// readCredentialsFile reads a json credentials file, refusing files readable by other users
+func readCredentialsFile(path string) (error, secrets) {
+	var s secrets
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read credentials file"), s
+	}
+
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		return errors.Errorf("credentials file %s is accessible by other users, restrict it with chmod 600", path), s
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read credentials file"), s
+	}
+
+	if err := json.Unmarshal(content, &s); err != nil {
+		return errors.Wrapf(err, "failed to parse credentials file %s", path), s
+	}
+
+	return nil, s
+}
+
+// runCredentialCommand runs the command through the shell and returns its output as the secret
+func runCredentialCommand(command string) (error, string) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	// let the helper prompt the user if it needs to
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "credential command failed"), ""
+	}
+
+	secret := strings.TrimRight(out.String(), "\r\n")
+	if secret == "" {
+		return errors.New("credential command returned an empty secret"), ""
+	}
+
+	return nil, secret
+}
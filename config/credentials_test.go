@@ -0,0 +1,132 @@
+package config
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func basicToken(user string, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(user + ":" + password))
+}
+
+func setEnv(t *testing.T, key string, val string) func() {
+	old, had := os.LookupEnv(key)
+	require.NoError(t, os.Setenv(key, val))
+
+	return func() {
+		if had {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	}
+}
+
+func TestCredentials_Environment(t *testing.T) {
+	r := assert.New(t)
+	path := writeConfig(t, `{"JiraUrl": "https://jira", "Credentials": {"Username": "user", "Password": "inline"}}`)
+	defer os.Remove(path)
+
+	defer setEnv(t, EnvPassword, "from-env")()
+
+	err, c := New(path)
+	r.NoError(err)
+	r.Equal(basicToken("user", "from-env"), c.AuthToken, "env password should override the inline one")
+}
+
+func TestCredentials_EnvironmentToken(t *testing.T) {
+	r := assert.New(t)
+	path := writeConfig(t, `{"JiraUrl": "https://jira", "Auth": {"Mode": "bearer"}}`)
+	defer os.Remove(path)
+
+	defer setEnv(t, EnvToken, "pat-from-env")()
+
+	err, c := New(path)
+	r.NoError(err)
+	r.Equal("pat-from-env", c.AuthToken)
+}
+
+func TestCredentials_File(t *testing.T) {
+	r := require.New(t)
+	dir, err := ioutil.TempDir("", "ferry-credentials")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+
+	credentials := filepath.Join(dir, "credentials.json")
+	r.NoError(ioutil.WriteFile(credentials, []byte(`{"Email": "me@example.com", "Token": "from-file"}`), 0600))
+
+	path := filepath.Join(dir, "config.json")
+	r.NoError(ioutil.WriteFile(path, []byte(`{"JiraUrl": "https://jira", "Auth": {"Mode": "api-token", "Token": "inline"}, "CredentialsFile": "credentials.json"}`), 0644))
+
+	err, c := New(path)
+	r.NoError(err)
+	r.Equal(basicToken("me@example.com", "from-file"), c.AuthToken)
+
+	// the environment wins over the file
+	defer setEnv(t, EnvToken, "from-env")()
+	err, c = New(path)
+	r.NoError(err)
+	r.Equal(basicToken("me@example.com", "from-env"), c.AuthToken)
+}
+
+func TestCredentials_FileTooOpen(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not checked on windows")
+	}
+
+	r := require.New(t)
+	dir, err := ioutil.TempDir("", "ferry-credentials")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+
+	credentials := filepath.Join(dir, "credentials.json")
+	r.NoError(ioutil.WriteFile(credentials, []byte(`{"Password": "secret"}`), 0644))
+
+	path := filepath.Join(dir, "config.json")
+	r.NoError(ioutil.WriteFile(path, []byte(`{"JiraUrl": "https://jira", "CredentialsFile": "`+credentials+`"}`), 0644))
+
+	err, _ = New(path)
+	r.Error(err)
+	r.Contains(err.Error(), "accessible by other users")
+}
+
+func TestCredentials_Command(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a posix shell")
+	}
+
+	r := assert.New(t)
+	path := writeConfig(t, `{"JiraUrl": "https://jira", "Credentials": {"Username": "user", "Password": "inline"}, "CredentialCommand": "printf 'from-command\n'"}`)
+	defer os.Remove(path)
+
+	err, c := New(path)
+	r.NoError(err)
+	r.Equal(basicToken("user", "from-command"), c.AuthToken)
+
+	// the command is not run when the environment already has the secret
+	defer setEnv(t, EnvPassword, "from-env")()
+	err, c = New(path)
+	r.NoError(err)
+	r.Equal(basicToken("user", "from-env"), c.AuthToken)
+}
+
+func TestCredentials_CommandFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a posix shell")
+	}
+
+	r := assert.New(t)
+	path := writeConfig(t, `{"JiraUrl": "https://jira", "Auth": {"Mode": "bearer"}, "CredentialCommand": "exit 3"}`)
+	defer os.Remove(path)
+
+	err, _ := New(path)
+	r.Error(err)
+	r.Contains(err.Error(), "credential command failed")
+}
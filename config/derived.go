@@ -0,0 +1,30 @@
+package config
+
+// DerivedField is a column computed from the sub tasks of the issue, named in
+// FieldsToRetrieve like any other field
+type DerivedField struct {
+	// Name is the name of the column, matched ignoring case
+	Name string `json:"Name"`
+	// Match selects the sub tasks aggregated, all of them when empty
+	Match SubTaskMatch `json:"Match"`
+	// Aggregate is one of count, estimate, timespent or first-assignee, the sums are in hours
+	Aggregate string `json:"Aggregate"`
+	// Buckets turn the number aggregated into the label of the first bucket holding it
+	Buckets []Bucket `json:"Buckets"`
+}
+
+// SubTaskMatch is the rule selecting the sub tasks of a derived field
+type SubTaskMatch struct {
+	// Types are the issue types of the sub tasks, matched ignoring case, any type when empty
+	Types []string `json:"Types"`
+	// Name is a regular expression the summary of the sub task must match
+	Name string `json:"Name"`
+	// ExcludeName is a regular expression the summary of the sub task must not match
+	ExcludeName string `json:"ExcludeName"`
+}
+
+// Bucket labels the values up to UpTo, a bucket without UpTo holds every value left
+type Bucket struct {
+	UpTo  *float64 `json:"UpTo"`
+	Label string   `json:"Label"`
+}
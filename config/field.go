@@ -0,0 +1,86 @@
+package config
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// Field is a column of the output, written in FieldsToRetrieve either as the
+// name of the field or as an object giving its header and format
+type Field struct {
+	// Field is the name, id or path of the field
+	Field string `json:"Field"`
+	// Header is the title of the column, the field when empty
+	Header string `json:"Header"`
+	// Format is one of date, datetime, number, hours, days or boolean, values are written as is when empty
+	Format string `json:"Format"`
+	// Layout is the Go time layout of the date and datetime formats
+	Layout string `json:"Layout"`
+	// HoursPerDay is the length of a day of the days format, 8 by default
+	HoursPerDay float64 `json:"HoursPerDay"`
+	// Sanitize lists the clean-ups applied to the values: commas, newlines or trim, values are kept intact when empty
+	Sanitize []string `json:"Sanitize"`
+}
+
+// NewFields gives the fields with the given names and no formatting
+func NewFields(names ...string) []Field {
+	fields := make([]Field, 0, len(names))
+	for _, name := range names {
+		fields = append(fields, Field{Field: name})
+	}
+
+	return fields
+}
+
+// UnmarshalJSON accepts the name of the field or the object form
+func (f *Field) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*f = Field{Field: name}
+		return nil
+	}
+
+	// an alias type does not have the UnmarshalJSON method
+	type field Field
+	var obj field
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return errors.Wrapf(err, "a field to retrieve must be a name or an object")
+	}
+
+	if obj.Field == "" {
+		return errors.Errorf("the field to retrieve %s has no Field", string(data))
+	}
+
+	*f = Field(obj)
+	return nil
+}
+
+// Title gives the header of the column
+func (f Field) Title() string {
+	if f.Header != "" {
+		return f.Header
+	}
+
+	return f.Field
+}
+
+// FieldNames gives the names of the fields to retrieve
+func (c *Configuration) FieldNames() []string {
+	names := make([]string, 0, len(c.FieldsToRetrieve))
+	for _, field := range c.FieldsToRetrieve {
+		names = append(names, field.Field)
+	}
+
+	return names
+}
+
+// Headers gives the header of the columns of the output
+func (c *Configuration) Headers() []string {
+	headers := make([]string, 0, len(c.FieldsToRetrieve))
+	for _, field := range c.FieldsToRetrieve {
+		headers = append(headers, field.Title())
+	}
+
+	return headers
+}
@@ -0,0 +1,11 @@
+package config
+
+// Flow configures the lead time, cycle time, time in status and reopen count columns computed from the changelog
+type Flow struct {
+	// Start are the statuses or status categories starting the cycle time, the indeterminate category by default
+	Start []string `json:"Start"`
+	// Done are the statuses or status categories ending it, the done category by default
+	Done []string `json:"Done"`
+	// Unit of the durations, hours or days, days by default
+	Unit string `json:"Unit"`
+}
@@ -0,0 +1,36 @@
+package httprequest
+
+import "net/http"
+
+// Authorizer sets the credentials of the requests sent to jira
+type Authorizer interface {
+	Authorize(req *http.Request) error
+}
+
+// Refresher is implemented by authorizers able to renew credentials rejected by jira
+type Refresher interface {
+	// Refresh renews the credentials, rejected is the Authorization header of the rejected request
+	Refresh(rejected string) error
+}
+
+// TokenAuth sends the same Authorization header with every request, such as
+// "Basic <base64 user:password>" or "Bearer <personal access token>"
+type TokenAuth struct {
+	Scheme string
+	Token  string
+}
+
+// Authorize sets the Authorization header, nothing is sent without a token
+func (a TokenAuth) Authorize(req *http.Request) error {
+	if a.Token == "" {
+		return nil
+	}
+
+	scheme := a.Scheme
+	if scheme == "" {
+		scheme = "Basic"
+	}
+
+	req.Header.Set("Authorization", scheme+" "+a.Token)
+	return nil
+}
@@ -1,22 +1,89 @@
 package httprequest
 
+import (
+	"context"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
 // JiraClient represents a basic API client for Jira Rest API
 type JiraClient struct {
-	URL       string
-	AuthToken string
+	URL   string
+	Auth  Authorizer
+	Retry RetryPolicy
+	// Limiter throttles every attempt sent by the client, nil means no limit
+	Limiter *RateLimiter
+	// InFlight bounds the attempts sent at once, a slot being held for one
+	// attempt and not while waiting to retry it, nil means no limit
+	InFlight chan struct{}
 }
 
 // NewClient create a new instance of API client
-func NewClient(URL, authToken string) *JiraClient {
+func NewClient(URL string, auth Authorizer) *JiraClient {
 	return &JiraClient{
-		URL,
-		authToken,
+		URL:   URL,
+		Auth:  auth,
+		Retry: DefaultRetryPolicy(),
 	}
 }
 
-// Get process the Jira Rest API authenticated request
-func (c *JiraClient) Get(path string, params map[string]string) []byte {
-	req := NewHTTPRequest(c.URL, path, c.AuthToken, params)
+// Get process the Jira Rest API authenticated request, retrying it according to the retry policy.
+// When jira rejects credentials that can be refreshed, they are renewed once and the request sent again.
+func (c *JiraClient) Get(path string, params map[string]string) ([]byte, error) {
+	refreshed := false
+
+	return c.Retry.do(func(ctx context.Context) ([]byte, error) {
+		if err := c.Limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		if err := c.acquire(ctx); err != nil {
+			return nil, err
+		}
+		defer c.release()
+
+		req := NewHTTPRequest(c.URL, path, c.Auth, params)
+		body, err := req.SendContext(ctx)
+
+		refresher, ok := c.Auth.(Refresher)
+		if !ok || refreshed || !isUnauthorized(err) {
+			return body, err
+		}
+
+		refreshed = true
+		if err := refresher.Refresh(req.authorization); err != nil {
+			return nil, err
+		}
+
+		if err := c.Limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		return NewHTTPRequest(c.URL, path, c.Auth, params).SendContext(ctx)
+	})
+}
+
+// acquire waits for a free slot to send an attempt or for the end of the context
+func (c *JiraClient) acquire(ctx context.Context) error {
+	if c.InFlight == nil {
+		return nil
+	}
+
+	select {
+	case c.InFlight <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return errors.Wrapf(ctx.Err(), "waiting for a free connection")
+	}
+}
+
+func (c *JiraClient) release() {
+	if c.InFlight != nil {
+		<-c.InFlight
+	}
+}
 
-	return req.Send()
+func isUnauthorized(err error) bool {
+	var authErr *AuthError
+	return errors.As(err, &authErr) && authErr.StatusCode == http.StatusUnauthorized
 }
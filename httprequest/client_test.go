@@ -0,0 +1,312 @@
+package httprequest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func serveStatus(status int, body string, header map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range header {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+}
+
+// newTestClient gives a client that does not retry
+func newTestClient(URL string) *JiraClient {
+	c := NewClient(URL, TokenAuth{Token: "token"})
+	c.Retry = RetryPolicy{MaxAttempts: 1}
+	return c
+}
+
+func TestJiraClient_GetSuccess(t *testing.T) {
+	r := require.New(t)
+	api := serveStatus(http.StatusOK, `{"total": 0}`, nil)
+	defer api.Close()
+
+	body, err := newTestClient(api.URL).Get("/rest/api/2/search", map[string]string{"jql": "project = POS"})
+	r.NoError(err)
+	r.Equal(`{"total": 0}`, string(body))
+}
+
+func TestJiraClient_GetAuthError(t *testing.T) {
+	r := require.New(t)
+	api := serveStatus(http.StatusUnauthorized, "<html><body>Unauthorized</body></html>", nil)
+	defer api.Close()
+
+	_, err := newTestClient(api.URL).Get("/rest/api/2/field", nil)
+	r.Error(err)
+
+	var authErr *AuthError
+	r.True(errors.As(err, &authErr), "expected an AuthError, got %T", err)
+	r.Equal(http.StatusUnauthorized, authErr.StatusCode)
+	r.Empty(authErr.Messages, "html body should not be reported")
+	r.Equal("authentication failed, check the credentials: 401 Unauthorized on /rest/api/2/field", err.Error())
+}
+
+func TestJiraClient_GetNotFound(t *testing.T) {
+	r := require.New(t)
+	api := serveStatus(http.StatusNotFound, `{"errorMessages":["Issue does not exist or you do not have permission to see it."],"errors":{}}`, nil)
+	defer api.Close()
+
+	_, err := newTestClient(api.URL).Get("/rest/api/2/issue/1", nil)
+
+	var notFound *NotFoundError
+	r.True(errors.As(err, &notFound), "expected a NotFoundError, got %T", err)
+	r.Equal([]string{"Issue does not exist or you do not have permission to see it."}, notFound.Messages)
+	r.Contains(err.Error(), "404 Not Found on /rest/api/2/issue/1: Issue does not exist")
+}
+
+func TestJiraClient_GetRateLimited(t *testing.T) {
+	r := require.New(t)
+	api := serveStatus(http.StatusTooManyRequests, "", map[string]string{"Retry-After": "30"})
+	defer api.Close()
+
+	_, err := newTestClient(api.URL).Get("/rest/api/2/search", nil)
+
+	var rateLimit *RateLimitError
+	r.True(errors.As(err, &rateLimit), "expected a RateLimitError, got %T", err)
+	r.Equal(30*time.Second, rateLimit.RetryAfter)
+}
+
+func TestJiraClient_GetServerError(t *testing.T) {
+	r := require.New(t)
+	api := serveStatus(http.StatusBadGateway, "Bad Gateway", nil)
+	defer api.Close()
+
+	_, err := newTestClient(api.URL).Get("/rest/api/2/search", nil)
+
+	var serverErr *ServerError
+	r.True(errors.As(err, &serverErr), "expected a ServerError, got %T", err)
+	r.Equal(http.StatusBadGateway, serverErr.StatusCode)
+}
+
+func TestJiraClient_GetBadRequest(t *testing.T) {
+	r := require.New(t)
+	api := serveStatus(http.StatusBadRequest, `{"errorMessages":[],"errors":{"jql":"Field 'foo' does not exist."}}`, nil)
+	defer api.Close()
+
+	_, err := newTestClient(api.URL).Get("/rest/api/2/search", nil)
+
+	var statusErr *StatusError
+	r.True(errors.As(err, &statusErr), "expected a StatusError, got %T", err)
+	r.Equal("jira request failed: 400 Bad Request on /rest/api/2/search: jql: Field 'foo' does not exist.", err.Error())
+}
+
+func TestJiraClient_GetConnectionError(t *testing.T) {
+	api := serveStatus(http.StatusOK, "", nil)
+	api.Close()
+
+	_, err := newTestClient(api.URL).Get("/rest/api/2/search", nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to send request to /rest/api/2/search")
+}
+
+// flakyServer fails with the given statuses before answering successfully
+func flakyServer(statuses []int, header map[string]string) (*httptest.Server, *int32) {
+	var calls int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		if int(n) <= len(statuses) {
+			for k, v := range header {
+				w.Header().Set(k, v)
+			}
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		w.Write([]byte(`{"total": 0}`))
+	})), &calls
+}
+
+func TestJiraClient_RetryTemporaryErrors(t *testing.T) {
+	r := require.New(t)
+	api, calls := flakyServer([]int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusTooManyRequests}, nil)
+	defer api.Close()
+
+	c := NewClient(api.URL, TokenAuth{Token: "token"})
+	c.Retry = RetryPolicy{MaxAttempts: 4, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+	body, err := c.Get("/rest/api/2/search", nil)
+	r.NoError(err)
+	r.Equal(`{"total": 0}`, string(body))
+	r.EqualValues(4, atomic.LoadInt32(calls))
+}
+
+func TestJiraClient_RetryGivesUp(t *testing.T) {
+	r := require.New(t)
+	api, calls := flakyServer([]int{502, 502, 502, 502}, nil)
+	defer api.Close()
+
+	c := NewClient(api.URL, TokenAuth{Token: "token"})
+	c.Retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+
+	_, err := c.Get("/rest/api/2/search", nil)
+	r.Error(err)
+	r.Contains(err.Error(), "giving up after 3 attempts")
+	r.EqualValues(3, atomic.LoadInt32(calls))
+
+	var serverErr *ServerError
+	r.True(errors.As(err, &serverErr), "expected a ServerError, got %T", err)
+}
+
+func TestJiraClient_NoRetryOnPermanentErrors(t *testing.T) {
+	r := require.New(t)
+	api, calls := flakyServer([]int{http.StatusUnauthorized}, nil)
+	defer api.Close()
+
+	c := NewClient(api.URL, TokenAuth{Token: "token"})
+	c.Retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+
+	_, err := c.Get("/rest/api/2/search", nil)
+	r.Error(err)
+	r.EqualValues(1, atomic.LoadInt32(calls))
+}
+
+func TestJiraClient_RetryHonoursRetryAfter(t *testing.T) {
+	r := require.New(t)
+	api, calls := flakyServer([]int{http.StatusTooManyRequests}, map[string]string{"Retry-After": "1"})
+	defer api.Close()
+
+	c := NewClient(api.URL, TokenAuth{Token: "token"})
+	var waits []time.Duration
+	c.Retry = RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond, sleep: func(d time.Duration) {
+		waits = append(waits, d)
+	}}
+
+	_, err := c.Get("/rest/api/2/search", nil)
+	r.NoError(err)
+	r.Equal([]time.Duration{time.Second}, waits, "expected to wait for Retry-After")
+	r.EqualValues(2, atomic.LoadInt32(calls))
+}
+
+func TestJiraClient_RetryDeadline(t *testing.T) {
+	r := require.New(t)
+	api, calls := flakyServer([]int{http.StatusTooManyRequests}, map[string]string{"Retry-After": "60"})
+	defer api.Close()
+
+	c := NewClient(api.URL, TokenAuth{Token: "token"})
+	c.Retry = RetryPolicy{MaxAttempts: 5, MaxElapsed: time.Second}
+
+	_, err := c.Get("/rest/api/2/search", nil)
+
+	var rateLimit *RateLimitError
+	r.True(errors.As(err, &rateLimit), "expected a RateLimitError, got %T", err)
+	r.EqualValues(1, atomic.LoadInt32(calls), "retry after exceeds the deadline")
+}
+
+func TestJiraClient_RetryDeadlineHangingServer(t *testing.T) {
+	r := require.New(t)
+
+	// the server accepts the connection and never responds
+	hang := make(chan struct{})
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-hang
+	}))
+	defer api.Close()
+	defer close(hang)
+
+	c := NewClient(api.URL, TokenAuth{Token: "token"})
+	c.Retry = RetryPolicy{MaxAttempts: 3, MaxElapsed: 200 * time.Millisecond, BaseDelay: time.Millisecond}
+
+	_, err := c.Get("/rest/api/2/search", nil)
+	r.Error(err)
+	r.Contains(err.Error(), "no response within 200ms")
+}
+
+func TestJiraClient_SlotReleasedWhileRetrying(t *testing.T) {
+	r := require.New(t)
+
+	// the first attempt on the search is rate limited, the fields answer at once
+	limited := make(chan struct{})
+	var calls int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/rest/api/2/search" && atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			close(limited)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer api.Close()
+
+	// the search waits for Retry-After until the fields are fetched
+	fetched := make(chan struct{})
+	c := NewClient(api.URL, TokenAuth{Token: "token"})
+	c.Retry = RetryPolicy{MaxAttempts: 2, sleep: func(time.Duration) { <-fetched }}
+	c.InFlight = make(chan struct{}, 1)
+
+	searched := make(chan error, 1)
+	go func() {
+		_, err := c.Get("/rest/api/2/search", nil)
+		searched <- err
+	}()
+
+	<-limited
+	_, err := c.Get("/rest/api/2/field", nil)
+	r.NoError(err)
+
+	close(fetched)
+	r.NoError(<-searched)
+}
+
+func TestJiraClient_RetryDeadlineCoversRateLimit(t *testing.T) {
+	r := require.New(t)
+	api, calls := flakyServer(nil, nil)
+	defer api.Close()
+
+	c := NewClient(api.URL, TokenAuth{Token: "token"})
+	c.Retry = RetryPolicy{MaxAttempts: 3, MaxElapsed: 50 * time.Millisecond}
+	c.Limiter = NewRateLimiter(0.001, 1)
+
+	_, err := c.Get("/rest/api/2/search", nil)
+	r.NoError(err)
+
+	// the next token comes in 1000s, far after the deadline
+	_, err = c.Get("/rest/api/2/search", nil)
+	r.Error(err)
+	r.Contains(err.Error(), "waiting for the rate limit")
+	r.EqualValues(1, atomic.LoadInt32(calls))
+}
+
+func TestRetryPolicy_DelayIsBounded(t *testing.T) {
+	r := require.New(t)
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	for attempt := 1; attempt < 10; attempt++ {
+		d := p.delay(attempt, &ServerError{&StatusError{StatusCode: 503}})
+		r.True(d <= time.Second, "delay %s over the max", d)
+		r.True(d >= 50*time.Millisecond, "delay %s under half the base delay", d)
+	}
+}
+
+func TestJiraClient_AuthorizationHeader(t *testing.T) {
+	r := require.New(t)
+
+	var header string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		header = req.Header.Get("Authorization")
+	}))
+	defer api.Close()
+
+	_, err := NewClient(api.URL, TokenAuth{Token: "dXNlcjpzZWNyZXQ="}).Get("/rest/api/2/field", nil)
+	r.NoError(err)
+	r.Equal("Basic dXNlcjpzZWNyZXQ=", header)
+
+	_, err = NewClient(api.URL, TokenAuth{Scheme: "Bearer", Token: "pat"}).Get("/rest/api/2/field", nil)
+	r.NoError(err)
+	r.Equal("Bearer pat", header)
+
+	_, err = NewClient(api.URL, nil).Get("/rest/api/2/field", nil)
+	r.NoError(err)
+	r.Empty(header)
+}
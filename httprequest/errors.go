@@ -0,0 +1,152 @@
+package httprequest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatusError is returned when jira answers with an unsuccessful status code
+type StatusError struct {
+	StatusCode int
+	Path       string
+	// Messages holds the errorMessages and errors reported by jira, if any
+	Messages []string
+	// RetryAfter is the delay requested by the Retry-After header, zero when absent
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return e.describe("jira request failed")
+}
+
+func (e *StatusError) describe(reason string) string {
+	msg := fmt.Sprintf("%s: %d %s on %s", reason, e.StatusCode, http.StatusText(e.StatusCode), e.Path)
+	if len(e.Messages) > 0 {
+		msg += ": " + strings.Join(e.Messages, "; ")
+	}
+
+	return msg
+}
+
+// AuthError is returned when jira rejects the credentials (401) or the access (403)
+type AuthError struct {
+	*StatusError
+}
+
+func (e *AuthError) Error() string {
+	return e.describe("authentication failed, check the credentials")
+}
+
+// NotFoundError is returned when the requested resource does not exist (404)
+type NotFoundError struct {
+	*StatusError
+}
+
+func (e *NotFoundError) Error() string {
+	return e.describe("not found")
+}
+
+// RateLimitError is returned when jira throttles the client (429)
+type RateLimitError struct {
+	*StatusError
+}
+
+func (e *RateLimitError) Error() string {
+	return e.describe("rate limited by jira")
+}
+
+// ServerError is returned when jira fails to process the request (5xx)
+type ServerError struct {
+	*StatusError
+}
+
+func (e *ServerError) Error() string {
+	return e.describe("jira server error")
+}
+
+// newStatusError builds the typed error matching the status code of the response
+func newStatusError(resp *http.Response, path string, body []byte) error {
+	status := &StatusError{
+		StatusCode: resp.StatusCode,
+		Path:       path,
+		Messages:   errorMessages(body),
+		RetryAfter: retryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return &AuthError{status}
+	case resp.StatusCode == http.StatusNotFound:
+		return &NotFoundError{status}
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return &RateLimitError{status}
+	case resp.StatusCode >= 500:
+		return &ServerError{status}
+	}
+
+	return status
+}
+
+// connectionError marks a failure to reach jira, as opposed to an error answered by jira
+type connectionError struct {
+	err error
+}
+
+func (e *connectionError) Error() string {
+	return e.err.Error()
+}
+
+func (e *connectionError) Unwrap() error {
+	return e.err
+}
+
+// errorMessages extracts the messages of a jira error collection, the body
+// is ignored when it is not one (an HTML error page for instance)
+func errorMessages(body []byte) []string {
+	var collection struct {
+		ErrorMessages []string          `json:"errorMessages"`
+		Errors        map[string]string `json:"errors"`
+	}
+
+	if err := json.Unmarshal(body, &collection); err != nil {
+		return nil
+	}
+
+	messages := collection.ErrorMessages
+
+	keys := make([]string, 0, len(collection.Errors))
+	for k := range collection.Errors {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		messages = append(messages, k+": "+collection.Errors[k])
+	}
+
+	return messages
+}
+
+// retryAfter parses a Retry-After header given either in seconds or as an HTTP date
+func retryAfter(val string) time.Duration {
+	if val == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(strings.TrimSpace(val)); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(val); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
@@ -1,44 +1,71 @@
 package httprequest
 
 import (
+	"context"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+
+	"github.com/pkg/errors"
 )
 
+// httpClient is shared by all the requests so that connections are reused
+var httpClient = &http.Client{}
+
 //HTTPRequest represents the apps request
 type HTTPRequest struct {
-	URL       string
-	Path      string
-	AuthToken string
-	Params    map[string]string
+	URL    string
+	Path   string
+	Auth   Authorizer
+	Params map[string]string
+
+	// authorization is the header sent, kept to refresh rejected credentials
+	authorization string
 }
 
-//Send sends the request
-func (httpreq *HTTPRequest) Send() []byte {
-	client := &http.Client{}
-	resp, err := client.Do(httpreq.get())
-	HandleError(err)
+//Send sends the request, an unsuccessful status code is returned as one of the typed errors
+func (httpreq *HTTPRequest) Send() ([]byte, error) {
+	return httpreq.SendContext(context.Background())
+}
+
+// SendContext sends the request like Send, giving up when the context is done
+func (httpreq *HTTPRequest) SendContext(ctx context.Context) ([]byte, error) {
+	req, err := httpreq.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(&connectionError{err}, "failed to send request to %s", httpreq.Path)
+	}
 
 	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
-	HandleError(err)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read response of %s", httpreq.Path)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, newStatusError(resp, httpreq.Path, body)
+	}
 
-	return body
+	return body, nil
 }
 
 //NewHTTPRequest ..
-func NewHTTPRequest(url string, path string, authToken string, params map[string]string) *HTTPRequest {
-	return &HTTPRequest{URL: url, Path: path, AuthToken: authToken, Params: params}
+func NewHTTPRequest(url string, path string, auth Authorizer, params map[string]string) *HTTPRequest {
+	return &HTTPRequest{URL: url, Path: path, Auth: auth, Params: params}
 }
 
-func (httpreq *HTTPRequest) get() *http.Request {
+func (httpreq *HTTPRequest) get(ctx context.Context) (*http.Request, error) {
 	var finalPath string
-	bearer := "Basic " + httpreq.AuthToken
 	if httpreq.Params != nil {
 		var endPoint *url.URL
 		endPoint, err := url.Parse(httpreq.URL)
-		HandleError(err)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid jira url")
+		}
 
 		endPoint.Path += httpreq.Path
 		parameters := url.Values{}
@@ -54,16 +81,17 @@ func (httpreq *HTTPRequest) get() *http.Request {
 		finalPath = httpreq.URL + httpreq.Path
 	}
 
-	req, err := http.NewRequest("GET", finalPath, nil)
-	req.Header.Add("Authorization", bearer)
-	HandleError(err)
-
-	return req
-}
-
-//HandleError handles errors
-func HandleError(err error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", finalPath, nil)
 	if err != nil {
-		panic(err.Error())
+		return nil, errors.Wrapf(err, "invalid request")
 	}
+
+	if httpreq.Auth != nil {
+		if err := httpreq.Auth.Authorize(req); err != nil {
+			return nil, errors.Wrapf(err, "failed to authorize request")
+		}
+		httpreq.authorization = req.Header.Get("Authorization")
+	}
+
+	return req, nil
 }
@@ -0,0 +1,81 @@
+package httprequest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLimiter returns a limiter whose clock stands still and which records
+// the waits instead of sleeping
+func newTestLimiter(perSecond float64, burst int) (*RateLimiter, *[]time.Duration) {
+	now := time.Now()
+	waits := []time.Duration{}
+
+	l := NewRateLimiter(perSecond, burst)
+	l.last = now
+	l.now = func() time.Time { return now }
+	l.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+
+	return l, &waits
+}
+
+func TestRateLimiter_Burst(t *testing.T) {
+	r := require.New(t)
+	l, _ := newTestLimiter(1, 3)
+
+	for i := 0; i < 3; i++ {
+		r.Zero(l.reserve(), "request %d should be allowed by the burst", i)
+	}
+	r.Equal(time.Second, l.reserve(), "fourth request should wait for a new token")
+}
+
+func TestRateLimiter_SteadyRate(t *testing.T) {
+	r := require.New(t)
+	l, waits := newTestLimiter(100, 1)
+
+	for i := 0; i < 4; i++ {
+		r.NoError(l.Wait(context.Background()))
+	}
+
+	// one token available at once, then one every 10ms
+	r.Equal([]time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond}, *waits)
+}
+
+func TestRateLimiter_CancelReturnsToken(t *testing.T) {
+	r := require.New(t)
+	l, _ := newTestLimiter(100, 1)
+	l.sleep = func(ctx context.Context, d time.Duration) error { return context.DeadlineExceeded }
+
+	r.NoError(l.Wait(context.Background()))
+	r.Error(l.Wait(context.Background()))
+	r.Equal(10*time.Millisecond, l.reserve(), "the cancelled wait should not hold a token")
+}
+
+func TestRateLimiter_Disabled(t *testing.T) {
+	var l *RateLimiter
+	require.Zero(t, l.reserve())
+	require.Zero(t, NewRateLimiter(0, 0).reserve())
+}
+
+func TestJiraClient_RateLimited(t *testing.T) {
+	r := require.New(t)
+	api := serveStatus(http.StatusOK, `{}`, nil)
+	defer api.Close()
+
+	c := newTestClient(api.URL)
+	l, waits := newTestLimiter(20, 1)
+	c.Limiter = l
+
+	for i := 0; i < 3; i++ {
+		_, err := c.Get("/rest/api/2/field", nil)
+		r.NoError(err)
+	}
+	r.Equal([]time.Duration{50 * time.Millisecond, 100 * time.Millisecond}, *waits)
+}
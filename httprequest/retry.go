@@ -0,0 +1,155 @@
+package httprequest
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RetryPolicy controls how failed requests are retried. Connection failures,
+// rate limiting and temporary server errors are retried, other errors are not.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts per request, including the first one
+	MaxAttempts int
+	// MaxElapsed bounds the total time spent on one request and its retries, zero means no limit
+	MaxElapsed time.Duration
+	// BaseDelay is the delay before the first retry, it doubles after every attempt
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between two attempts
+	MaxDelay time.Duration
+
+	// sleep waits between two attempts, time.Sleep when nil, replaced by the tests
+	sleep func(time.Duration)
+}
+
+// DefaultRetryPolicy gives the policy used by new clients
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		MaxElapsed:  2 * time.Minute,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    30 * time.Second,
+	}
+}
+
+var (
+	jitterMu sync.Mutex
+	jitter   = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// retryable tells whether the request failing with err may succeed when sent again
+func retryable(err error) bool {
+	var connErr *connectionError
+	if errors.As(err, &connErr) {
+		return true
+	}
+
+	var rateLimit *RateLimitError
+	if errors.As(err, &rateLimit) {
+		return true
+	}
+
+	var serverErr *ServerError
+	if errors.As(err, &serverErr) {
+		switch serverErr.StatusCode {
+		case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+	}
+
+	return false
+}
+
+// delay gives the time to wait before the next attempt, honouring the
+// Retry-After header when jira sent one
+func (p RetryPolicy) delay(attempt int, err error) time.Duration {
+	if wait := retryAfterOf(err); wait > 0 {
+		return wait
+	}
+
+	backoff := p.BaseDelay << uint(attempt-1)
+	if backoff <= 0 || (p.MaxDelay > 0 && backoff > p.MaxDelay) {
+		backoff = p.MaxDelay
+	}
+
+	if backoff <= 0 {
+		return 0
+	}
+
+	// keep half of the backoff and randomize the rest so that workers do not retry in lockstep
+	jitterMu.Lock()
+	defer jitterMu.Unlock()
+
+	half := backoff / 2
+	return half + time.Duration(jitter.Int63n(int64(backoff-half)+1))
+}
+
+// retryAfterOf gives the delay requested by jira along with err, if any
+func retryAfterOf(err error) time.Duration {
+	var rateLimit *RateLimitError
+	if errors.As(err, &rateLimit) {
+		return rateLimit.RetryAfter
+	}
+
+	var serverErr *ServerError
+	if errors.As(err, &serverErr) {
+		return serverErr.RetryAfter
+	}
+
+	return 0
+}
+
+// do sends the request until it succeeds, fails with a permanent error or the policy gives up.
+// The context given to send ends with MaxElapsed so that an attempt cannot hang past it
+func (p RetryPolicy) do(send func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	start := time.Now()
+
+	ctx := context.Background()
+	if p.MaxElapsed > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, start.Add(p.MaxElapsed))
+		defer cancel()
+	}
+
+	for attempt := 1; ; attempt++ {
+		body, err := send(ctx)
+		if err == nil {
+			return body, nil
+		}
+
+		if ctx.Err() != nil {
+			return nil, giveUp(errors.Wrapf(err, "no response within %s", p.MaxElapsed), attempt)
+		}
+
+		if !retryable(err) {
+			return nil, err
+		}
+
+		if attempt >= p.MaxAttempts {
+			return nil, giveUp(err, attempt)
+		}
+
+		wait := p.delay(attempt, err)
+		if p.MaxElapsed > 0 && time.Since(start)+wait > p.MaxElapsed {
+			return nil, giveUp(err, attempt)
+		}
+
+		if p.sleep != nil {
+			p.sleep(wait)
+		} else {
+			time.Sleep(wait)
+		}
+	}
+}
+
+func giveUp(err error, attempts int) error {
+	if attempts == 1 {
+		return err
+	}
+
+	return errors.Wrapf(err, "giving up after %d attempts", attempts)
+}
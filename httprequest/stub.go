@@ -18,6 +18,9 @@ func (c *JiraClient) UseStub() {
 
 		issueReq, _ := regexp.Compile("/rest/api/2/issue/([0-9]+)(\\?(.*))?$")
 		searchReq, _ := regexp.Compile("/rest/api/2/search(\\?(.*))?$")
+		filterReq, _ := regexp.Compile("/rest/api/2/filter/([0-9]+)$")
+		boardsReq, _ := regexp.Compile("/rest/agile/1.0/board(\\?(.*))?$")
+		sprintsReq, _ := regexp.Compile("/rest/agile/1.0/board/([0-9]+)/sprint(\\?(.*))?$")
 
 		switch {
 		case r.RequestURI == "/rest/api/2/field":
@@ -1450,6 +1453,58 @@ func (c *JiraClient) UseStub() {
   }
 }`, m[1], issueType)
 
+		case r.RequestURI == "/rest/api/2/status":
+			resp = `[
+  {"id": "10000", "name": "To Do", "statusCategory": {"id": 2, "key": "new", "name": "To Do"}},
+  {"id": "3", "name": "In Progress", "statusCategory": {"id": 4, "key": "indeterminate", "name": "In Progress"}},
+  {"id": "10001", "name": "In Review", "statusCategory": {"id": 4, "key": "indeterminate", "name": "In Progress"}},
+  {"id": "10002", "name": "Done", "statusCategory": {"id": 3, "key": "done", "name": "Done"}}
+]`
+
+		case filterReq.MatchString(r.RequestURI):
+			m := filterReq.FindStringSubmatch(r.RequestURI)
+			resp = fmt.Sprintf(`{
+  "id": "%s",
+  "name": "POS backlog",
+  "jql": "project = POS AND status != Done ORDER BY Rank ASC"
+}`, m[1])
+
+		case boardsReq.MatchString(r.RequestURI):
+			// like jira, boards containing the name are returned
+			resp = `{
+  "maxResults": 50,
+  "startAt": 0,
+  "isLast": true,
+  "values": [
+    {
+      "id": 1,
+      "name": "POS board",
+      "type": "scrum"
+    },
+    {
+      "id": 2,
+      "name": "POS board (archived)",
+      "type": "scrum"
+    }
+  ]
+}`
+
+		case sprintsReq.MatchString(r.RequestURI):
+			sprints := map[string]string{
+				"closed": `{"id": 2, "name": "Sprint 1", "state": "closed", "startDate": "2020-08-03T09:00:00.000Z"}`,
+				"active": `{"id": 3, "name": "Sprint 2", "state": "active", "startDate": "2020-08-17T09:00:00.000Z"}`,
+				"future": `{"id": 5, "name": "Sprint 4", "state": "future", "startDate": "2020-09-14T09:00:00.000Z"}, {"id": 4, "name": "Sprint 3", "state": "future", "startDate": "2020-08-31T09:00:00.000Z"}`,
+			}
+
+			values := make([]string, 0)
+			for _, state := range []string{"closed", "active", "future"} {
+				if s := r.URL.Query().Get("state"); s == "" || strings.Contains(s, state) {
+					values = append(values, sprints[state])
+				}
+			}
+
+			resp = `{"maxResults": 50, "startAt": 0, "isLast": true, "values": [` + strings.Join(values, ", ") + `]}`
+
 		default:
 			resp = `{
   "id": "https://docs.atlassian.com/jira/REST/schema/error-collection#",
@@ -0,0 +1,173 @@
+package jirafinder
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gojira/ferry/config"
+	"github.com/pkg/errors"
+)
+
+// Strategies of an attribution rule
+const (
+	AttributeFirstAuthor      = "first-author"
+	AttributeLastAuthor       = "last-author"
+	AttributeAssignee         = "assignee"
+	AttributeFrequentAssignee = "most-frequent-assignee"
+)
+
+// AttributionColumn is the column giving the name of the rule which attributed the issue
+const AttributionColumn = "attribution"
+
+// legacyAttribution attributes the bugs to whoever moved them to In Development,
+// it is used when the config has no Attribution
+var legacyAttribution = []config.AttributionRule{
+	{
+		Name:       "bug",
+		IssueTypes: []string{"bug", "functional bug", "production issue"},
+		Statuses:   []string{"In Development"},
+		Strategy:   AttributeFirstAuthor,
+	},
+}
+
+// compileAttribution checks the attribution rules of the config and names the
+// unnamed ones, the legacy rule is used when there is none
+func compileAttribution(rules []config.AttributionRule) (error, []config.AttributionRule) {
+	if rules == nil {
+		rules = legacyAttribution
+	}
+
+	compiled := make([]config.AttributionRule, 0, len(rules))
+	for i, rule := range rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule %d", i+1)
+		}
+
+		rule.Strategy = strings.ToLower(rule.Strategy)
+		switch rule.Strategy {
+		case "":
+			rule.Strategy = AttributeFirstAuthor
+		case AttributeFirstAuthor, AttributeLastAuthor, AttributeAssignee, AttributeFrequentAssignee:
+		default:
+			return errors.Errorf("unknown strategy '%s' of attribution %s, expected first-author, last-author, assignee or most-frequent-assignee", rule.Strategy, rule.Name), nil
+		}
+
+		if len(rule.Statuses) == 0 {
+			return errors.Errorf("attribution %s has no Statuses", rule.Name), nil
+		}
+
+		compiled = append(compiled, rule)
+	}
+
+	return nil, compiled
+}
+
+// attribute gives the first rule attributing the issue and the developer it
+// found, both empty when no rule applies or no transition matches
+func attribute(rules []config.AttributionRule, issue map[string]interface{}, issueType string) (string, string) {
+	var histories []history
+
+	for _, rule := range rules {
+		if len(rule.IssueTypes) > 0 && !containsFold(rule.IssueTypes, issueType) {
+			continue
+		}
+
+		if histories == nil {
+			histories = parseChangelog(issue)
+		}
+
+		if name := developer(rule, histories, currentAssignee(issue)); name != "" {
+			return rule.Name, name
+		}
+	}
+
+	return "", ""
+}
+
+// developer applies the strategy of the rule to the transitions to its statuses
+func developer(rule config.AttributionRule, histories []history, current string) string {
+	transitions := make([]history, 0)
+	for _, h := range histories {
+		if change, ok := h.fieldChange("status"); ok && containsFold(rule.Statuses, change.To) {
+			transitions = append(transitions, h)
+		}
+	}
+
+	if len(transitions) == 0 {
+		return ""
+	}
+
+	switch rule.Strategy {
+	case AttributeLastAuthor:
+		return transitions[len(transitions)-1].Author
+	case AttributeAssignee:
+		return assigneeAt(histories, transitions[0].Created, current)
+	case AttributeFrequentAssignee:
+		// ties go to the assignee seen first
+		counts := make(map[string]int)
+		frequent := ""
+		for _, t := range transitions {
+			assignee := assigneeAt(histories, t.Created, current)
+			if assignee == "" {
+				continue
+			}
+
+			counts[assignee]++
+			if counts[assignee] > counts[frequent] {
+				frequent = assignee
+			}
+		}
+		return frequent
+	}
+
+	return transitions[0].Author
+}
+
+// assigneeAt gives the assignee of the issue at the time, replaying the
+// assignee changes of the changelog from the current assignee
+func assigneeAt(histories []history, at time.Time, current string) string {
+	assignee, changed := "", false
+
+	for _, h := range histories {
+		change, ok := h.fieldChange("assignee")
+		if !ok {
+			continue
+		}
+
+		if h.Created.After(at) {
+			// the first change after the time tells who was assigned before it
+			if !changed {
+				return change.From
+			}
+			break
+		}
+
+		assignee, changed = change.To, true
+	}
+
+	if changed {
+		return assignee
+	}
+
+	return current
+}
+
+// currentAssignee gives the display name of the assignee of the issue, empty when unassigned
+func currentAssignee(issue map[string]interface{}) string {
+	fields, _ := issue["fields"].(map[string]interface{})
+	assignee, _ := fields["assignee"].(map[string]interface{})
+	name, _ := assignee["displayName"].(string)
+
+	return name
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), strings.TrimSpace(value)) {
+			return true
+		}
+	}
+
+	return false
+}
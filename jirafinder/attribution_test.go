@@ -0,0 +1,144 @@
+package jirafinder
+
+import (
+	"testing"
+
+	"github.com/gojira/ferry/config"
+	"github.com/stretchr/testify/require"
+)
+
+func change(author, created string, items ...map[string]interface{}) map[string]interface{} {
+	changes := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		changes = append(changes, item)
+	}
+
+	return map[string]interface{}{
+		"author":  map[string]interface{}{"displayName": author},
+		"created": created,
+		"items":   changes,
+	}
+}
+
+func item(field, from, to string) map[string]interface{} {
+	return map[string]interface{}{"field": field, "fromString": from, "toString": to}
+}
+
+// attributedIssue is reassigned from Ann to Bob then Cid, and goes twice in progress
+func attributedIssue() map[string]interface{} {
+	return map[string]interface{}{
+		"fields": map[string]interface{}{
+			"assignee": map[string]interface{}{"displayName": "Cid"},
+		},
+		"changelog": map[string]interface{}{
+			// jira may not sort the histories
+			"histories": []interface{}{
+				change("Bob", "2020-08-21T09:00:00.000+0200", item("status", "In Review", "In Progress")),
+				change("Ann", "2020-08-17T09:00:00.000+0200", item("status", "To Do", "In Progress")),
+				change("Lead", "2020-08-18T09:00:00.000+0200", item("assignee", "Ann", "Bob")),
+				change("Bob", "2020-08-19T09:00:00.000+0200", item("status", "In Progress", "In Review"), item("assignee", "Bob", "Cid")),
+				change("Cid", "2020-08-22T09:00:00.000+0200", item("status", "In Progress", "Coding")),
+			},
+		},
+	}
+}
+
+func TestAttribute_Strategies(t *testing.T) {
+	tests := []struct {
+		strategy string
+		want     string
+	}{
+		{"", "Ann"},
+		{AttributeFirstAuthor, "Ann"},
+		{AttributeLastAuthor, "Cid"},
+		{AttributeAssignee, "Ann"},
+		{AttributeFrequentAssignee, "Cid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			r := require.New(t)
+
+			err, rules := compileAttribution([]config.AttributionRule{
+				{IssueTypes: []string{"Defect"}, Statuses: []string{"in progress", "Coding"}, Strategy: tt.strategy},
+			})
+			r.NoError(err)
+
+			rule, developer := attribute(rules, attributedIssue(), "defect")
+			r.Equal("rule 1", rule)
+			r.Equal(tt.want, developer)
+		})
+	}
+}
+
+func TestAttribute_Rules(t *testing.T) {
+	r := require.New(t)
+
+	err, rules := compileAttribution([]config.AttributionRule{
+		{Name: "review", IssueTypes: []string{"Story"}, Statuses: []string{"In Review"}},
+		{Name: "released", Statuses: []string{"Released"}},
+		{Name: "coding", Statuses: []string{"Coding"}, Strategy: "Assignee"},
+	})
+	r.NoError(err)
+
+	rule, developer := attribute(rules, attributedIssue(), "Bug")
+	r.Equal("coding", rule)
+	r.Equal("Cid", developer)
+
+	rule, developer = attribute(rules, attributedIssue(), "story")
+	r.Equal("review", rule)
+	r.Equal("Bob", developer)
+
+	rule, developer = attribute(rules[:2], attributedIssue(), "Bug")
+	r.Equal("", rule)
+	r.Equal("", developer)
+}
+
+func TestAttribute_Legacy(t *testing.T) {
+	r := require.New(t)
+
+	err, rules := compileAttribution(nil)
+	r.NoError(err)
+
+	issue := attributedIssue()
+	issue["changelog"].(map[string]interface{})["histories"] = append(issue["changelog"].(map[string]interface{})["histories"].([]interface{}),
+		change("Dan", "2020-08-23T09:00:00.000+0200", item("status", "Coding", "In Development")))
+
+	rule, developer := attribute(rules, issue, "Production Issue")
+	r.Equal("bug", rule)
+	r.Equal("Dan", developer)
+
+	rule, developer = attribute(rules, issue, "Story")
+	r.Equal("", rule)
+	r.Equal("", developer)
+}
+
+func TestAssigneeAt(t *testing.T) {
+	r := require.New(t)
+	histories := parseChangelog(attributedIssue())
+
+	r.Equal("Ann", assigneeAt(histories, parseJiraTime("2020-08-17T10:00:00.000+0200"), "Cid"))
+	r.Equal("Bob", assigneeAt(histories, parseJiraTime("2020-08-18T09:00:00.000+0200"), "Cid"))
+	r.Equal("Cid", assigneeAt(histories, parseJiraTime("2020-08-25T09:00:00.000+0200"), "Cid"))
+	r.Equal("Cid", assigneeAt(nil, parseJiraTime("2020-08-17T10:00:00.000+0200"), "Cid"))
+}
+
+func TestCompileAttribution_Invalid(t *testing.T) {
+	r := require.New(t)
+
+	err, _ := compileAttribution([]config.AttributionRule{{Name: "dev", Statuses: []string{"Coding"}, Strategy: "reporter"}})
+	r.Error(err)
+	r.Contains(err.Error(), "unknown strategy 'reporter' of attribution dev")
+
+	err, _ = compileAttribution([]config.AttributionRule{{Strategy: "last-author"}})
+	r.Error(err)
+	r.Contains(err.Error(), "attribution rule 1 has no Statuses")
+}
+
+func TestGetFieldValueAttribution(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal("coding", getFieldValue("Attribution", JiraIssue{Attribution: "coding", AssigneeName: "Cid"}))
+	r.Equal("Cid", getFieldValue("assignee", JiraIssue{Attribution: "coding", AssigneeName: "Cid"}))
+	r.Equal("N/A", getFieldValue("attribution", JiraIssue{}))
+}
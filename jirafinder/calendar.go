@@ -0,0 +1,264 @@
+package jirafinder
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gojira/ferry/config"
+	"github.com/pkg/errors"
+)
+
+const holidayLayout = "2006-01-02"
+
+// calendar counts the working time between two dates
+type calendar struct {
+	location *time.Location
+	days     [7]bool
+	// start and end are the working hours, as offsets from midnight
+	start    time.Duration
+	end      time.Duration
+	holidays map[string]bool
+}
+
+// newCalendar reads the calendar of the config, nil when there is none
+func newCalendar(c *config.Calendar) (error, *calendar) {
+	if c == nil {
+		return nil, nil
+	}
+
+	cal := &calendar{location: time.UTC, end: 24 * time.Hour, holidays: make(map[string]bool)}
+
+	if c.Timezone != "" {
+		location, err := time.LoadLocation(c.Timezone)
+		if err != nil {
+			return errors.Wrapf(err, "invalid Timezone of the calendar"), nil
+		}
+		cal.location = location
+	}
+
+	days := c.WorkingDays
+	if len(days) == 0 {
+		days = []string{"monday", "tuesday", "wednesday", "thursday", "friday"}
+	}
+	for _, name := range days {
+		day, ok := parseWeekday(name)
+		if !ok {
+			return errors.Errorf("unknown working day '%s' of the calendar", name), nil
+		}
+		cal.days[day] = true
+	}
+
+	if c.WorkingHours != "" {
+		err, start, end := parseWorkingHours(c.WorkingHours)
+		if err != nil {
+			return err, nil
+		}
+		cal.start, cal.end = start, end
+	}
+
+	for _, date := range c.Holidays {
+		day, err := time.Parse(holidayLayout, strings.TrimSpace(date))
+		if err != nil {
+			return errors.Errorf("invalid holiday '%s' of the calendar, expected a date such as 2020-12-25", date), nil
+		}
+		cal.holidays[day.Format(holidayLayout)] = true
+	}
+
+	if c.HolidaysFile != "" {
+		err, dates := readICalendarDates(c.HolidaysFile)
+		if err != nil {
+			return err, nil
+		}
+		for _, date := range dates {
+			cal.holidays[date] = true
+		}
+	}
+
+	return nil, cal
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if len(name) < 3 {
+		return 0, false
+	}
+
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.HasPrefix(strings.ToLower(day.String()), name) {
+			return day, true
+		}
+	}
+
+	return 0, false
+}
+
+// parseWorkingHours reads working hours such as 09:00-17:30
+func parseWorkingHours(hours string) (error, time.Duration, time.Duration) {
+	parts := strings.Split(hours, "-")
+	if len(parts) == 2 {
+		errStart, start := parseClock(parts[0])
+		errEnd, end := parseClock(parts[1])
+
+		if errStart == nil && errEnd == nil && end > start {
+			return nil, start, end
+		}
+	}
+
+	return errors.Errorf("invalid WorkingHours '%s' of the calendar, expected the start and end of the day such as 09:00-17:00", hours), 0, 0
+}
+
+// parseClock reads a time of the day such as 09:30, 24:00 being the end of the day
+func parseClock(clock string) (error, time.Duration) {
+	clock = strings.TrimSpace(clock)
+	if clock == "24:00" {
+		return nil, 24 * time.Hour
+	}
+
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return err, 0
+	}
+
+	return nil, time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+}
+
+// readICalendarDates gives the days covered by the events of an iCalendar
+// file, the recurrence of the events is not expanded
+func readICalendarDates(path string) (error, []string) {
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open holidays file"), nil
+	}
+	defer file.Close()
+
+	// long lines are folded on the next lines starting with a space
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.Wrapf(err, "failed to read holidays file"), nil
+	}
+
+	dates := make([]string, 0)
+	var start, end time.Time
+	inEvent := false
+
+	for _, line := range lines {
+		name, value := splitICalendarLine(line)
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			inEvent, start, end = true, time.Time{}, time.Time{}
+
+		case name == "END" && value == "VEVENT" && inEvent:
+			inEvent = false
+			if start.IsZero() {
+				return errors.Errorf("an event of holidays file %s has no DTSTART", path), nil
+			}
+
+			// the end of a day event is the day after it
+			if end.IsZero() || !end.After(start) {
+				end = start.AddDate(0, 0, 1)
+			}
+			for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+				dates = append(dates, day.Format(holidayLayout))
+			}
+
+		case inEvent && (name == "DTSTART" || name == "DTEND"):
+			if len(value) < 8 {
+				return errors.Errorf("invalid %s '%s' in holidays file %s", name, value, path), nil
+			}
+
+			day, err := time.Parse("20060102", value[:8])
+			if err != nil {
+				return errors.Errorf("invalid %s '%s' in holidays file %s", name, value, path), nil
+			}
+
+			if name == "DTSTART" {
+				start = day
+			} else {
+				// an event ending during a day covers that day
+				if len(value) > 8 && value[8:] != "T000000" && value[8:] != "T000000Z" {
+					day = day.AddDate(0, 0, 1)
+				}
+				end = day
+			}
+		}
+	}
+
+	return nil, dates
+}
+
+// splitICalendarLine gives the name of the property, without its parameters, and its value
+func splitICalendarLine(line string) (string, string) {
+	i := strings.IndexByte(line, ':')
+	if i < 0 {
+		return "", ""
+	}
+
+	name := line[:i]
+	if j := strings.IndexByte(name, ';'); j >= 0 {
+		name = name[:j]
+	}
+
+	return strings.ToUpper(strings.TrimSpace(name)), strings.TrimSpace(line[i+1:])
+}
+
+// isWorkingDay tells if the day is worked, the time being in the location of the calendar
+func (c *calendar) isWorkingDay(day time.Time) bool {
+	return c.days[day.Weekday()] && !c.holidays[day.Format(holidayLayout)]
+}
+
+// workingTime gives the working time between the dates
+func (c *calendar) workingTime(from time.Time, to time.Time) time.Duration {
+	if !to.After(from) {
+		return 0
+	}
+
+	from, to = from.In(c.location), to.In(c.location)
+
+	total := time.Duration(0)
+	for day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, c.location); day.Before(to); day = day.AddDate(0, 0, 1) {
+		if !c.isWorkingDay(day) {
+			continue
+		}
+
+		start, end := c.workingHoursOf(day)
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+
+		if end.After(start) {
+			total += end.Sub(start)
+		}
+	}
+
+	return total
+}
+
+// workingHoursOf gives the start and end of the working hours of the day, on the wall clock of the calendar
+func (c *calendar) workingHoursOf(day time.Time) (time.Time, time.Time) {
+	clock := func(offset time.Duration) time.Time {
+		minutes := int(offset / time.Minute)
+		return time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, c.location)
+	}
+
+	return clock(c.start), clock(c.end)
+}
+
+// hoursPerDay gives the length of a working day
+func (c *calendar) hoursPerDay() float64 {
+	return (c.end - c.start).Hours()
+}
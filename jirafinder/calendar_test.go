@@ -0,0 +1,133 @@
+package jirafinder
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gojira/ferry/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendar_WorkingTime(t *testing.T) {
+	r := require.New(t)
+
+	err, cal := newCalendar(&config.Calendar{
+		WorkingHours: "09:00-17:00",
+		Timezone:     "Europe/Paris",
+		Holidays:     []string{"2020-08-19"},
+	})
+	r.NoError(err)
+
+	paris, _ := time.LoadLocation("Europe/Paris")
+	at := func(day int, hour int) time.Time {
+		return time.Date(2020, 8, day, hour, 0, 0, 0, paris)
+	}
+
+	// monday evening to thursday morning, wednesday off
+	r.Equal(10*time.Hour, cal.workingTime(at(17, 16), at(20, 10)))
+	// friday afternoon to monday morning
+	r.Equal(4*time.Hour, cal.workingTime(at(21, 15), at(24, 11)))
+	// the working hours are in the timezone of the calendar
+	r.Equal(time.Hour, cal.workingTime(time.Date(2020, 8, 17, 14, 0, 0, 0, time.UTC), time.Date(2020, 8, 17, 20, 0, 0, 0, time.UTC)))
+	r.Equal(time.Duration(0), cal.workingTime(at(20, 10), at(17, 16)))
+	r.Equal(8.0, cal.hoursPerDay())
+}
+
+func TestCalendar_Defaults(t *testing.T) {
+	r := require.New(t)
+
+	err, cal := newCalendar(&config.Calendar{})
+	r.NoError(err)
+
+	friday := time.Date(2020, 8, 21, 12, 0, 0, 0, time.UTC)
+	r.Equal(24*time.Hour, cal.workingTime(friday, friday.AddDate(0, 0, 3)))
+	r.Equal(time.Duration(0), cal.workingTime(friday.AddDate(0, 0, 1), friday.AddDate(0, 0, 2)))
+	r.Equal(24.0, cal.hoursPerDay())
+
+	err, cal = newCalendar(&config.Calendar{WorkingDays: []string{"Sat", "sunday"}, WorkingHours: "20:00-24:00"})
+	r.NoError(err)
+	r.Equal(8*time.Hour, cal.workingTime(friday, friday.AddDate(0, 0, 3)))
+
+	err, cal = newCalendar(nil)
+	r.NoError(err)
+	r.Nil(cal)
+}
+
+func TestCalendar_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		calendar config.Calendar
+		err      string
+	}{
+		{"timezone", config.Calendar{Timezone: "Mars/Olympus"}, "invalid Timezone"},
+		{"day", config.Calendar{WorkingDays: []string{"mo"}}, "unknown working day 'mo'"},
+		{"hours", config.Calendar{WorkingHours: "17:00-09:00"}, "invalid WorkingHours '17:00-09:00'"},
+		{"holiday", config.Calendar{Holidays: []string{"25/12/2020"}}, "invalid holiday '25/12/2020'"},
+		{"file", config.Calendar{HolidaysFile: "no/holidays.ics"}, "failed to open holidays file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err, _ := newCalendar(&tt.calendar)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestReadICalendarDates(t *testing.T) {
+	r := require.New(t)
+
+	file, err := ioutil.TempFile("", "holidays-*.ics")
+	r.NoError(err)
+	defer os.Remove(file.Name())
+
+	_, err = file.WriteString("BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20201224\r\nDTEND;VALUE=DATE:20201226\r\nSUMMARY:Christmas\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART:20201231T120000Z\r\nDTEND:20201231T180000Z\r\nSUMMARY:New year's eve af\r\n ternoon\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20210101\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n")
+	r.NoError(err)
+	r.NoError(file.Close())
+
+	err, dates := readICalendarDates(file.Name())
+	r.NoError(err)
+	r.Equal([]string{"2020-12-24", "2020-12-25", "2020-12-31", "2021-01-01"}, dates)
+
+	err, cal := newCalendar(&config.Calendar{HolidaysFile: file.Name()})
+	r.NoError(err)
+	r.False(cal.isWorkingDay(time.Date(2020, 12, 25, 0, 0, 0, 0, time.UTC)))
+	r.True(cal.isWorkingDay(time.Date(2020, 12, 28, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestFlowValues_Calendar(t *testing.T) {
+	r := require.New(t)
+
+	f := newFlowFinder(config.Flow{}, "time in in progress", "time in in review")
+	err, cal := newCalendar(&config.Calendar{WorkingHours: "09:00-17:00"})
+	r.NoError(err)
+	f.calendar = cal
+
+	// the review since friday evening did not last any working hour on saturday
+	r.Equal(map[string]string{
+		"time in in progress": "2",
+		"time in in review":   "1",
+	}, f.flowValues(flowIssue(nil), parseJiraTime("2020-08-22T09:00:00.000+0000")))
+}
+
+func TestJiraFinder_CalendarHoursPerDay(t *testing.T) {
+	r := require.New(t)
+	err, c := config.New("../example_config/sample_for_test.json")
+	r.NoError(err)
+
+	c.FieldsToRetrieve = []config.Field{{Field: "timespent", Format: "days"}, {Field: "timeestimate", Format: "days", HoursPerDay: 6}}
+	c.Calendar = &config.Calendar{WorkingHours: "09:00-16:30"}
+	err, f := NewJiraFinder(c)
+	r.NoError(err)
+
+	r.Equal(7.5, f.Config.FieldsToRetrieve[0].HoursPerDay)
+	r.Equal(6.0, f.Config.FieldsToRetrieve[1].HoursPerDay)
+	r.Equal(0.0, c.FieldsToRetrieve[0].HoursPerDay, "the config of the caller is left untouched")
+}
@@ -0,0 +1,83 @@
+package jirafinder
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// changeItem is the change of one field in a history of the changelog
+type changeItem struct {
+	Field string
+	From  string
+	To    string
+}
+
+// history is an entry of the changelog: the changes made at once by an author
+type history struct {
+	Author  string
+	Created time.Time
+	Items   []changeItem
+}
+
+// parseChangelog gives the histories of an issue fetched with its changelog, oldest first
+func parseChangelog(issue map[string]interface{}) []history {
+	changelog, _ := issue["changelog"].(map[string]interface{})
+	entries, _ := changelog["histories"].([]interface{})
+
+	histories := make([]history, 0, len(entries))
+	for _, entry := range entries {
+		h, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		author, _ := h["author"].(map[string]interface{})
+		name, _ := author["displayName"].(string)
+		created, _ := h["created"].(string)
+
+		items, _ := h["items"].([]interface{})
+		changes := make([]changeItem, 0, len(items))
+		for _, item := range items {
+			i, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			field, _ := i["field"].(string)
+			from, _ := i["fromString"].(string)
+			to, _ := i["toString"].(string)
+			changes = append(changes, changeItem{Field: field, From: from, To: to})
+		}
+
+		histories = append(histories, history{Author: name, Created: parseJiraTime(created), Items: changes})
+	}
+
+	sort.SliceStable(histories, func(i, j int) bool {
+		return histories[i].Created.Before(histories[j].Created)
+	})
+
+	return histories
+}
+
+// parseJiraTime parses a date of jira, the zero time when it cannot be read
+func parseJiraTime(value string) time.Time {
+	for _, layout := range jiraDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// fieldChange gives the change of the field in the history, matching the field ignoring case
+func (h history) fieldChange(field string) (changeItem, bool) {
+	for _, item := range h.Items {
+		if strings.EqualFold(item.Field, field) {
+			return item, true
+		}
+	}
+
+	return changeItem{}, false
+}
@@ -0,0 +1,220 @@
+package jirafinder
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gojira/ferry/config"
+	"github.com/pkg/errors"
+)
+
+// Aggregations of the sub tasks matched by a derived field
+const (
+	AggregateCount         = "count"
+	AggregateEstimate      = "estimate"
+	AggregateTimeSpent     = "timespent"
+	AggregateFirstAssignee = "first-assignee"
+)
+
+// legacyDerivedFields are the columns computed when the config has no
+// DerivedFields: the developer, the functional bugs and the complexity
+// estimated by the development sub tasks
+var legacyDerivedFields = []config.DerivedField{
+	{
+		Name:      "assignee",
+		Match:     config.SubTaskMatch{Name: "Dev", ExcludeName: "code review"},
+		Aggregate: AggregateFirstAssignee,
+	},
+	{
+		Name:      "bug count",
+		Match:     config.SubTaskMatch{Types: []string{"Functional Bug"}},
+		Aggregate: AggregateCount,
+	},
+	{
+		Name:      "complexity",
+		Match:     config.SubTaskMatch{Name: "Dev", ExcludeName: "code review"},
+		Aggregate: AggregateEstimate,
+		Buckets: []config.Bucket{
+			{UpTo: upTo(8), Label: "Extra Small"},
+			{UpTo: upTo(16), Label: "Small"},
+			{UpTo: upTo(24), Label: "Medium"},
+			{UpTo: upTo(32), Label: "Large"},
+			{Label: "Complex"},
+		},
+	},
+}
+
+func upTo(h float64) *float64 {
+	return &h
+}
+
+// derivedField is a derived field with its regular expressions compiled
+type derivedField struct {
+	config.DerivedField
+	name        *regexp.Regexp
+	excludeName *regexp.Regexp
+}
+
+// compileDerivedFields checks the derived fields of the config, the legacy ones are used when there is none
+func compileDerivedFields(fields []config.DerivedField) (error, []derivedField) {
+	if fields == nil {
+		fields = legacyDerivedFields
+	}
+
+	compiled := make([]derivedField, 0, len(fields))
+	names := make(map[string]bool)
+
+	for _, field := range fields {
+		if strings.TrimSpace(field.Name) == "" {
+			return errors.New("a derived field has no Name"), nil
+		}
+
+		name := strings.ToLower(field.Name)
+		if names[name] {
+			return errors.Errorf("derived field %s is defined twice", field.Name), nil
+		}
+		names[name] = true
+
+		d := derivedField{DerivedField: field}
+		d.Aggregate = strings.ToLower(field.Aggregate)
+
+		switch d.Aggregate {
+		case AggregateCount, AggregateEstimate, AggregateTimeSpent:
+		case AggregateFirstAssignee:
+			if len(field.Buckets) > 0 {
+				return errors.Errorf("derived field %s cannot bucket the first assignee", field.Name), nil
+			}
+		default:
+			return errors.Errorf("unknown aggregate '%s' of derived field %s, expected count, estimate, timespent or first-assignee", field.Aggregate, field.Name), nil
+		}
+
+		var err error
+		if field.Match.Name != "" {
+			if d.name, err = regexp.Compile(field.Match.Name); err != nil {
+				return errors.Wrapf(err, "invalid Name of derived field %s", field.Name), nil
+			}
+		}
+		if field.Match.ExcludeName != "" {
+			if d.excludeName, err = regexp.Compile(field.Match.ExcludeName); err != nil {
+				return errors.Wrapf(err, "invalid ExcludeName of derived field %s", field.Name), nil
+			}
+		}
+
+		if err := validateBuckets(field); err != nil {
+			return err, nil
+		}
+
+		compiled = append(compiled, d)
+	}
+
+	return nil, compiled
+}
+
+// validateBuckets checks that the bounds of the buckets grow and that only the last one is unbounded
+func validateBuckets(field config.DerivedField) error {
+	for i, b := range field.Buckets {
+		if b.UpTo == nil {
+			if i != len(field.Buckets)-1 {
+				return errors.Errorf("only the last bucket of derived field %s can omit UpTo", field.Name)
+			}
+			continue
+		}
+
+		if i > 0 && *b.UpTo <= *field.Buckets[i-1].UpTo {
+			return errors.Errorf("the buckets of derived field %s must be sorted by UpTo", field.Name)
+		}
+	}
+
+	return nil
+}
+
+// isDerivedField tells if the column is computed from the sub tasks
+func (f *JiraFinder) isDerivedField(name string) bool {
+	for _, d := range f.derived {
+		if strings.EqualFold(d.Name, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isComputedField tells if the column is computed by ferry rather than retrieved from jira
+func (f *JiraFinder) isComputedField(name string) bool {
+	return f.isDerivedField(name) || strings.EqualFold(name, AttributionColumn) || f.isFlowField(name)
+}
+
+// deriveValues computes the derived fields from the sub tasks, keyed by their lower case name
+func deriveValues(fields []derivedField, subTasks []SubTask) map[string]string {
+	values := make(map[string]string, len(fields))
+	for _, d := range fields {
+		values[strings.ToLower(d.Name)] = d.value(subTasks)
+	}
+
+	return values
+}
+
+func (d derivedField) matches(subTask SubTask) bool {
+	if len(d.Match.Types) > 0 {
+		found := false
+		for _, t := range d.Match.Types {
+			if strings.EqualFold(t, subTask.TaskType) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if d.name != nil && !d.name.MatchString(subTask.Name) {
+		return false
+	}
+
+	return d.excludeName == nil || !d.excludeName.MatchString(subTask.Name)
+}
+
+// value aggregates the matching sub tasks, N/A when no sub task gives a first assignee
+func (d derivedField) value(subTasks []SubTask) string {
+	total := 0.0
+	for _, subTask := range subTasks {
+		if !d.matches(subTask) {
+			continue
+		}
+
+		switch d.Aggregate {
+		case AggregateFirstAssignee:
+			return subTask.AssigneeName
+		case AggregateCount:
+			total++
+		case AggregateEstimate:
+			total += subTask.EstimateSeconds / 3600
+		case AggregateTimeSpent:
+			total += subTask.TimeSpentSeconds / 3600
+		}
+	}
+
+	if d.Aggregate == AggregateFirstAssignee {
+		return "N/A"
+	}
+
+	if len(d.Buckets) > 0 {
+		return bucketLabel(total, d.Buckets)
+	}
+
+	return strconv.FormatFloat(math.Round(total*100)/100, 'f', -1, 64)
+}
+
+// bucketLabel gives the label of the first bucket holding the value, N/A when it is above all of them
+func bucketLabel(value float64, buckets []config.Bucket) string {
+	for _, b := range buckets {
+		if b.UpTo == nil || value <= *b.UpTo {
+			return b.Label
+		}
+	}
+
+	return "N/A"
+}
@@ -0,0 +1,101 @@
+package jirafinder
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gojira/ferry/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriveValues(t *testing.T) {
+	r := require.New(t)
+
+	var fields []config.DerivedField
+	r.NoError(json.Unmarshal([]byte(`[
+		{"Name": "Developer", "Match": {"Name": "(?i)^(coding|in progress)"}, "Aggregate": "first-assignee"},
+		{"Name": "Defects", "Match": {"Types": ["defect", "Bug"]}, "Aggregate": "count"},
+		{"Name": "Hours spent", "Match": {"ExcludeName": "(?i)review"}, "Aggregate": "timespent"},
+		{"Name": "Size", "Aggregate": "Estimate", "Buckets": [{"UpTo": 4, "Label": "S"}, {"UpTo": 12, "Label": "M"}]}
+	]`), &fields))
+
+	err, derived := compileDerivedFields(fields)
+	r.NoError(err)
+
+	subTasks := []SubTask{
+		{Name: "Review", TaskType: "Sub-task", AssigneeName: "Ann", EstimateSeconds: 3600, TimeSpentSeconds: 1800},
+		{Name: "Coding the form", TaskType: "Sub-task", AssigneeName: "Bob", EstimateSeconds: 4 * 3600, TimeSpentSeconds: 5400},
+		{Name: "Broken layout", TaskType: "Defect", AssigneeName: "Bob", TimeSpentSeconds: 1200},
+		{Name: "Wrong total", TaskType: "bug"},
+	}
+
+	r.Equal(map[string]string{
+		"developer":   "Bob",
+		"defects":     "2",
+		"hours spent": "1.83",
+		"size":        "M",
+	}, deriveValues(derived, subTasks))
+
+	r.Equal(map[string]string{
+		"developer":   "N/A",
+		"defects":     "0",
+		"hours spent": "0",
+		"size":        "S",
+	}, deriveValues(derived, nil))
+
+	subTasks[1].EstimateSeconds = 20 * 3600
+	r.Equal("N/A", deriveValues(derived, subTasks)["size"])
+}
+
+func TestGetFieldValueDerived(t *testing.T) {
+	issue := JiraIssue{
+		Data:    map[string]interface{}{"fields": map[string]interface{}{"size": "ignored"}},
+		Derived: map[string]string{"size": "M"},
+	}
+
+	require.Equal(t, "M", getFieldValue("Size", issue))
+}
+
+func TestCompileDerivedFields_Invalid(t *testing.T) {
+	upTo := func(h float64) *float64 { return &h }
+
+	tests := []struct {
+		name  string
+		field config.DerivedField
+		err   string
+	}{
+		{"no name", config.DerivedField{Aggregate: "count"}, "has no Name"},
+		{"aggregate", config.DerivedField{Name: "Effort", Aggregate: "avg"}, "unknown aggregate 'avg' of derived field Effort"},
+		{"regexp", config.DerivedField{Name: "Effort", Aggregate: "count", Match: config.SubTaskMatch{Name: "("}}, "invalid Name of derived field Effort"},
+		{"assignee buckets", config.DerivedField{Name: "Dev", Aggregate: "first-assignee", Buckets: []config.Bucket{{Label: "x"}}}, "cannot bucket"},
+		{"unsorted buckets", config.DerivedField{Name: "Size", Aggregate: "estimate", Buckets: []config.Bucket{{UpTo: upTo(8), Label: "S"}, {UpTo: upTo(4), Label: "M"}}}, "must be sorted"},
+		{"unbounded bucket", config.DerivedField{Name: "Size", Aggregate: "estimate", Buckets: []config.Bucket{{Label: "S"}, {UpTo: upTo(4), Label: "M"}}}, "only the last bucket"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err, _ := compileDerivedFields([]config.DerivedField{tt.field})
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.err)
+		})
+	}
+
+	err, _ := compileDerivedFields([]config.DerivedField{{Name: "Size", Aggregate: "count"}, {Name: "size", Aggregate: "count"}})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "defined twice")
+}
+
+func TestJiraFinder_ProcessFieldsDerived(t *testing.T) {
+	r := require.New(t)
+	err, c := config.New("../example_config/sample_for_test.json")
+	r.NoError(err)
+
+	// Sprint is a field of jira, the derived field wins
+	c.FieldsToRetrieve = config.NewFields("key", "Sprint")
+	c.DerivedFields = []config.DerivedField{{Name: "sprint", Aggregate: "count"}}
+	err, f := NewJiraFinder(c)
+	r.NoError(err)
+
+	_, fields := f.processFields(testFields)
+	r.Equal([]string{"key", "Sprint"}, fields)
+}
@@ -0,0 +1,405 @@
+package jirafinder
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Process mining event log formats
+const (
+	FormatXES  = "xes"
+	FormatOCEL = "ocel"
+)
+
+const (
+	xesTimeLayout = "2006-01-02T15:04:05.000-07:00"
+	// ocelInitialTime is the time of the attribute values known from the start
+	ocelInitialTime = "1970-01-01T00:00:00Z"
+)
+
+// Trace is an issue seen as a case of an event log
+type Trace struct {
+	// CaseID is the key of the issue
+	CaseID string
+	// Attributes are the values of the fields to retrieve, in the order of the header
+	Attributes []string
+	// Events are the status changes of the issue, oldest first
+	Events []Event
+}
+
+// Event is a status change: the status entered is the activity and its author the resource
+type Event struct {
+	Activity string
+	Resource string
+	Time     time.Time
+}
+
+// EventLogWriter is implemented by the writers of event logs, which write an
+// issue with its changelog instead of a row
+type EventLogWriter interface {
+	WriteTrace(trace Trace) error
+}
+
+func isEventLogFormat(format string) bool {
+	return format == FormatXES || format == FormatOCEL
+}
+
+// trace gives the case of the issue, its attributes being the fields to retrieve
+func (f *JiraFinder) trace(issue JiraIssue) Trace {
+	columns := columnValues(issue)
+	formatColumns(columns, f.Config.FieldsToRetrieve)
+
+	key, _ := issue.Data["key"].(string)
+	trace := Trace{CaseID: key, Attributes: buildRows(columns, f.separator(), nil)[0], Events: make([]Event, 0)}
+
+	for _, h := range issue.changelog {
+		if change, ok := h.fieldChange("status"); ok {
+			trace.Events = append(trace.Events, Event{Activity: change.To, Resource: h.Author, Time: h.Created})
+		}
+	}
+
+	return trace
+}
+
+// xesWriter streams the traces as an XES log
+type xesWriter struct {
+	out    io.Closer
+	buf    *bufio.Writer
+	header []string
+}
+
+func newXesWriter(out io.WriteCloser) *xesWriter {
+	return &xesWriter{out: out, buf: bufio.NewWriter(out)}
+}
+
+func (w *xesWriter) WriteHeader(header []string) error {
+	w.header = header
+
+	_, err := w.buf.WriteString(xesProlog)
+	return errors.Wrapf(err, "failed to write output")
+}
+
+func (w *xesWriter) WriteRow(row []string) error {
+	return errors.New("the xes format writes the issues with their changelog, not rows")
+}
+
+func (w *xesWriter) WriteTrace(trace Trace) error {
+	var b strings.Builder
+
+	b.WriteString("\t<trace>\n")
+	fmt.Fprintf(&b, "\t\t<string key=\"concept:name\" value=\"%s\"/>\n", xmlAttr(trace.CaseID))
+	for i, name := range w.header {
+		if i < len(trace.Attributes) {
+			fmt.Fprintf(&b, "\t\t<string key=\"%s\" value=\"%s\"/>\n", xmlAttr(name), xmlAttr(trace.Attributes[i]))
+		}
+	}
+
+	for _, e := range trace.Events {
+		b.WriteString("\t\t<event>\n")
+		fmt.Fprintf(&b, "\t\t\t<string key=\"concept:name\" value=\"%s\"/>\n", xmlAttr(e.Activity))
+		fmt.Fprintf(&b, "\t\t\t<string key=\"org:resource\" value=\"%s\"/>\n", xmlAttr(e.Resource))
+		fmt.Fprintf(&b, "\t\t\t<date key=\"time:timestamp\" value=\"%s\"/>\n", e.Time.Format(xesTimeLayout))
+		b.WriteString("\t\t</event>\n")
+	}
+	b.WriteString("\t</trace>\n")
+
+	_, err := w.buf.WriteString(b.String())
+	return errors.Wrapf(err, "failed to write output")
+}
+
+func (w *xesWriter) Close() error {
+	if _, err := w.buf.WriteString("</log>\n"); err != nil {
+		w.out.Close()
+		return errors.Wrapf(err, "failed to write output")
+	}
+
+	if err := w.buf.Flush(); err != nil {
+		w.out.Close()
+		return errors.Wrapf(err, "failed to flush output")
+	}
+
+	return w.out.Close()
+}
+
+const xesProlog = `<?xml version="1.0" encoding="UTF-8"?>
+<log xes.version="1.0" xes.features="nested-attributes" xmlns="http://www.xes-standard.org/">
+	<extension name="Concept" prefix="concept" uri="http://www.xes-standard.org/concept.xesext"/>
+	<extension name="Time" prefix="time" uri="http://www.xes-standard.org/time.xesext"/>
+	<extension name="Organizational" prefix="org" uri="http://www.xes-standard.org/org.xesext"/>
+	<global scope="trace">
+		<string key="concept:name" value="__INVALID__"/>
+	</global>
+	<global scope="event">
+		<string key="concept:name" value="__INVALID__"/>
+		<string key="org:resource" value="__INVALID__"/>
+		<date key="time:timestamp" value="1970-01-01T00:00:00.000+00:00"/>
+	</global>
+	<classifier name="Activity" keys="concept:name"/>
+`
+
+// ocelWriter writes the traces as an OCEL 2.0 JSON log: the issues and the
+// authors are objects, of type issue and user, related to the events. The
+// types are listed before the objects, so the objects and events are spooled
+// to temporary files and the log is assembled when the writer is closed
+type ocelWriter struct {
+	out        io.WriteCloser
+	header     []string
+	objects    jsonSpool
+	events     jsonSpool
+	activities map[string]bool
+	users      map[string]bool
+}
+
+// jsonSpool holds the elements of a json array in a temporary file
+type jsonSpool struct {
+	pattern string
+	file    *os.File
+	buf     *bufio.Writer
+	count   int
+}
+
+func newOcelWriter(out io.WriteCloser) *ocelWriter {
+	return &ocelWriter{
+		out:        out,
+		objects:    jsonSpool{pattern: "ferry-objects-*.json"},
+		events:     jsonSpool{pattern: "ferry-events-*.json"},
+		activities: make(map[string]bool),
+		users:      make(map[string]bool),
+	}
+}
+
+type ocelType struct {
+	Name       string          `json:"name"`
+	Attributes []ocelAttribute `json:"attributes"`
+}
+
+type ocelAttribute struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+	Time string `json:"time,omitempty"`
+	// Value is only set on the attribute values
+	Value *string `json:"value,omitempty"`
+}
+
+type ocelObject struct {
+	ID            string             `json:"id"`
+	Type          string             `json:"type"`
+	Attributes    []ocelAttribute    `json:"attributes"`
+	Relationships []ocelRelationship `json:"relationships"`
+}
+
+type ocelEvent struct {
+	ID            string             `json:"id"`
+	Type          string             `json:"type"`
+	Time          string             `json:"time"`
+	Attributes    []ocelAttribute    `json:"attributes"`
+	Relationships []ocelRelationship `json:"relationships"`
+}
+
+type ocelRelationship struct {
+	ObjectID  string `json:"objectId"`
+	Qualifier string `json:"qualifier"`
+}
+
+// ocelLog is the layout of the log assembled by writeLog
+type ocelLog struct {
+	ObjectTypes []ocelType   `json:"objectTypes"`
+	EventTypes  []ocelType   `json:"eventTypes"`
+	Objects     []ocelObject `json:"objects"`
+	Events      []ocelEvent  `json:"events"`
+}
+
+func (w *ocelWriter) WriteHeader(header []string) error {
+	w.header = header
+	return nil
+}
+
+func (w *ocelWriter) WriteRow(row []string) error {
+	return errors.New("the ocel format writes the issues with their changelog, not rows")
+}
+
+func (w *ocelWriter) WriteTrace(trace Trace) error {
+	issue := ocelObject{ID: trace.CaseID, Type: "issue", Attributes: make([]ocelAttribute, 0, len(w.header)), Relationships: []ocelRelationship{}}
+	for i, name := range w.header {
+		if i < len(trace.Attributes) {
+			value := trace.Attributes[i]
+			issue.Attributes = append(issue.Attributes, ocelAttribute{Name: name, Time: ocelInitialTime, Value: &value})
+		}
+	}
+
+	if err := w.objects.append(issue); err != nil {
+		return err
+	}
+
+	for i, e := range trace.Events {
+		event := ocelEvent{
+			ID:            fmt.Sprintf("%s-%d", trace.CaseID, i+1),
+			Type:          e.Activity,
+			Time:          e.Time.Format(time.RFC3339),
+			Attributes:    []ocelAttribute{},
+			Relationships: []ocelRelationship{{ObjectID: trace.CaseID, Qualifier: "issue"}},
+		}
+
+		if e.Resource != "" {
+			event.Relationships = append(event.Relationships, ocelRelationship{ObjectID: ocelUserID(e.Resource), Qualifier: "resource"})
+			w.users[e.Resource] = true
+		}
+
+		w.activities[e.Activity] = true
+		if err := w.events.append(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *ocelWriter) Close() error {
+	defer w.objects.cleanup()
+	defer w.events.cleanup()
+
+	if err := w.writeLog(); err != nil {
+		w.out.Close()
+		return errors.Wrapf(err, "failed to write the event log")
+	}
+
+	return w.out.Close()
+}
+
+// writeLog writes the types known once all the traces are written, then
+// copies the spooled objects and events
+func (w *ocelWriter) writeLog() error {
+	issueType := ocelType{Name: "issue", Attributes: make([]ocelAttribute, 0, len(w.header))}
+	for _, name := range w.header {
+		issueType.Attributes = append(issueType.Attributes, ocelAttribute{Name: name, Type: "string"})
+	}
+	objectTypes := []ocelType{issueType, {Name: "user", Attributes: []ocelAttribute{}}}
+
+	eventTypes := make([]ocelType, 0, len(w.activities))
+	for _, activity := range sortedSet(w.activities) {
+		eventTypes = append(eventTypes, ocelType{Name: activity, Attributes: []ocelAttribute{}})
+	}
+
+	for _, user := range sortedSet(w.users) {
+		if err := w.objects.append(ocelObject{ID: ocelUserID(user), Type: "user", Attributes: []ocelAttribute{}, Relationships: []ocelRelationship{}}); err != nil {
+			return err
+		}
+	}
+
+	buf := bufio.NewWriter(w.out)
+	for _, part := range []func() error{
+		func() error { return writeJSONMember(buf, "{\n  \"objectTypes\": ", objectTypes) },
+		func() error { return writeJSONMember(buf, ",\n  \"eventTypes\": ", eventTypes) },
+		func() error { return w.objects.copyTo(buf, ",\n  \"objects\": ") },
+		func() error { return w.events.copyTo(buf, ",\n  \"events\": ") },
+	} {
+		if err := part(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := buf.WriteString("\n}\n"); err != nil {
+		return err
+	}
+
+	return buf.Flush()
+}
+
+// writeJSONMember writes a member of the log, indented as its second level
+func writeJSONMember(w io.Writer, name string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, name); err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// append writes an element of the array to the spool, creating its file on the first one
+func (s *jsonSpool) append(value interface{}) error {
+	if s.file == nil {
+		file, err := ioutil.TempFile("", s.pattern)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create temporary file")
+		}
+		s.file, s.buf = file, bufio.NewWriter(file)
+	}
+
+	data, err := json.MarshalIndent(value, "    ", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to build the event log")
+	}
+
+	separator := ",\n    "
+	if s.count == 0 {
+		separator = "\n    "
+	}
+	s.count++
+
+	if _, err := s.buf.WriteString(separator); err != nil {
+		return errors.Wrapf(err, "failed to write temporary file")
+	}
+
+	_, err = s.buf.Write(data)
+	return errors.Wrapf(err, "failed to write temporary file")
+}
+
+// copyTo writes the member holding the spooled array
+func (s *jsonSpool) copyTo(w io.Writer, name string) error {
+	if _, err := io.WriteString(w, name+"["); err != nil {
+		return err
+	}
+
+	if s.file != nil {
+		if err := s.buf.Flush(); err != nil {
+			return err
+		}
+
+		if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(w, s.file); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, "\n  ]")
+	return err
+}
+
+func (s *jsonSpool) cleanup() {
+	if s.file != nil {
+		s.file.Close()
+		os.Remove(s.file.Name())
+	}
+}
+
+// ocelUserID gives the id of the object of a user, apart from the issue keys
+func ocelUserID(name string) string {
+	return "user:" + name
+}
+
+func sortedSet(set map[string]bool) []string {
+	values := make([]string, 0, len(set))
+	for v := range set {
+		values = append(values, v)
+	}
+	sort.Strings(values)
+
+	return values
+}
@@ -0,0 +1,193 @@
+package jirafinder
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gojira/ferry/config"
+	"github.com/stretchr/testify/require"
+)
+
+func eventLogTrace() Trace {
+	return Trace{
+		CaseID:     "POS-7",
+		Attributes: []string{"POS-7", "Fix login & <signup>", "5"},
+		Events: []Event{
+			{Activity: "In Progress", Resource: "Ann", Time: parseJiraTime("2020-08-18T09:00:00.000+0200")},
+			{Activity: "Done", Resource: "", Time: parseJiraTime("2020-08-19T17:30:00.000+0000")},
+		},
+	}
+}
+
+func TestJiraFinder_Trace(t *testing.T) {
+	r := require.New(t)
+	err, c := config.New("../example_config/sample_for_test.json")
+	r.NoError(err)
+
+	c.FieldsToRetrieve = []config.Field{{Field: "key"}, {Field: "labels", Header: "Labels"}, {Field: "customfield_10016", Format: "number"}}
+	err, f := NewJiraFinder(c)
+	r.NoError(err)
+
+	issue := JiraIssue{
+		Data: map[string]interface{}{
+			"key": "POS-7",
+			"fields": map[string]interface{}{
+				"labels":            []interface{}{"backend", "urgent"},
+				"customfield_10016": "5.0",
+			},
+		},
+		Fields:    []string{"key", "labels", "customfield_10016"},
+		changelog: parseChangelog(attributedIssue()),
+	}
+
+	trace := f.trace(issue)
+	r.Equal("POS-7", trace.CaseID)
+	r.Equal([]string{"POS-7", "backend, urgent", "5"}, trace.Attributes)
+	r.Equal([]string{"In Progress", "In Review", "In Progress", "Coding"}, activities(trace))
+	r.Equal("Ann", trace.Events[0].Resource)
+	r.Equal("Cid", trace.Events[3].Resource)
+}
+
+func activities(trace Trace) []string {
+	names := make([]string, 0, len(trace.Events))
+	for _, e := range trace.Events {
+		names = append(names, e.Activity)
+	}
+
+	return names
+}
+
+func writeEventLog(t *testing.T, format string, traces ...Trace) string {
+	r := require.New(t)
+	out := &bufferCloser{}
+
+	err, w := newWriter(format, out)
+	r.NoError(err)
+	r.NoError(w.WriteHeader([]string{"key", "summary", "story points"}))
+	for _, trace := range traces {
+		r.NoError(w.(EventLogWriter).WriteTrace(trace))
+	}
+	r.Error(w.WriteRow([]string{"POS-8"}), "an event log has no rows")
+	r.NoError(w.Close())
+
+	return out.String()
+}
+
+func TestWriter_XES(t *testing.T) {
+	r := require.New(t)
+	out := writeEventLog(t, FormatXES, eventLogTrace())
+
+	r.True(strings.HasPrefix(out, "<?xml"))
+	r.Contains(out, "\t<trace>\n"+
+		"\t\t<string key=\"concept:name\" value=\"POS-7\"/>\n"+
+		"\t\t<string key=\"key\" value=\"POS-7\"/>\n"+
+		"\t\t<string key=\"summary\" value=\"Fix login &amp; &lt;signup&gt;\"/>\n"+
+		"\t\t<string key=\"story points\" value=\"5\"/>\n"+
+		"\t\t<event>\n"+
+		"\t\t\t<string key=\"concept:name\" value=\"In Progress\"/>\n"+
+		"\t\t\t<string key=\"org:resource\" value=\"Ann\"/>\n"+
+		"\t\t\t<date key=\"time:timestamp\" value=\"2020-08-18T09:00:00.000+02:00\"/>\n"+
+		"\t\t</event>\n")
+	r.True(strings.HasSuffix(out, "\t</trace>\n</log>\n"))
+
+	var log struct {
+		Traces []struct {
+			Events []struct{} `xml:"event"`
+		} `xml:"trace"`
+	}
+	r.NoError(xml.Unmarshal([]byte(out), &log))
+	r.Len(log.Traces, 1)
+	r.Len(log.Traces[0].Events, 2)
+}
+
+func TestWriter_OCEL(t *testing.T) {
+	r := require.New(t)
+	out := writeEventLog(t, FormatOCEL, eventLogTrace())
+
+	var log ocelLog
+	r.NoError(json.Unmarshal([]byte(out), &log))
+
+	r.Equal("issue", log.ObjectTypes[0].Name)
+	r.Equal([]ocelAttribute{{Name: "key", Type: "string"}, {Name: "summary", Type: "string"}, {Name: "story points", Type: "string"}}, log.ObjectTypes[0].Attributes)
+	r.Equal("user", log.ObjectTypes[1].Name)
+	r.Equal([]ocelType{{Name: "Done", Attributes: []ocelAttribute{}}, {Name: "In Progress", Attributes: []ocelAttribute{}}}, log.EventTypes)
+
+	r.Len(log.Objects, 2)
+	r.Equal("POS-7", log.Objects[0].ID)
+	r.Equal("Fix login & <signup>", *log.Objects[0].Attributes[1].Value)
+	r.Equal(ocelInitialTime, log.Objects[0].Attributes[1].Time)
+	r.Equal(ocelObject{ID: "user:Ann", Type: "user", Attributes: []ocelAttribute{}, Relationships: []ocelRelationship{}}, log.Objects[1])
+
+	r.Equal([]ocelEvent{
+		{
+			ID: "POS-7-1", Type: "In Progress", Time: "2020-08-18T09:00:00+02:00", Attributes: []ocelAttribute{},
+			Relationships: []ocelRelationship{{ObjectID: "POS-7", Qualifier: "issue"}, {ObjectID: "user:Ann", Qualifier: "resource"}},
+		},
+		{
+			ID: "POS-7-2", Type: "Done", Time: "2020-08-19T17:30:00Z", Attributes: []ocelAttribute{},
+			Relationships: []ocelRelationship{{ObjectID: "POS-7", Qualifier: "issue"}},
+		},
+	}, log.Events)
+
+	r.True(strings.HasPrefix(out, "{\n  \"objectTypes\": ["), "the types come first")
+}
+
+func TestWriter_OCELSpoolsTraces(t *testing.T) {
+	r := require.New(t)
+
+	second := eventLogTrace()
+	second.CaseID = "POS-8"
+	out := writeEventLog(t, FormatOCEL, eventLogTrace(), second)
+
+	var log ocelLog
+	r.NoError(json.Unmarshal([]byte(out), &log))
+	r.Len(log.Objects, 3, "two issues and their author")
+	r.Equal("POS-8", log.Objects[1].ID)
+	r.Len(log.Events, 4)
+	r.Equal("POS-8-2", log.Events[3].ID)
+
+	log = ocelLog{}
+	r.NoError(json.Unmarshal([]byte(writeEventLog(t, FormatOCEL)), &log))
+	r.Empty(log.Objects)
+	r.Empty(log.Events)
+}
+
+func TestJiraFinder_SearchEventLog(t *testing.T) {
+	r := require.New(t)
+	err, c := config.New("../example_config/sample_for_test.json")
+	r.NoError(err)
+
+	dir, err := ioutil.TempDir("", "ferry")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+
+	// the event logs ignore the transitions mode
+	c.DownloadPath = filepath.Join(dir, "issues.xes")
+	c.Mode = ModeTransitions
+	err, f := NewJiraFinder(c)
+	r.NoError(err)
+	f.UseStub()
+
+	r.NoError(f.Search())
+
+	content, err := ioutil.ReadFile(c.DownloadPath)
+	r.NoError(err)
+
+	var log struct {
+		Traces []struct {
+			Attributes []struct {
+				Key   string `xml:"key,attr"`
+				Value string `xml:"value,attr"`
+			} `xml:"string"`
+		} `xml:"trace"`
+	}
+	r.NoError(xml.Unmarshal(content, &log))
+	r.Len(log.Traces, 6)
+	r.Equal("concept:name", log.Traces[0].Attributes[0].Key)
+	r.Equal("summary", log.Traces[0].Attributes[2].Key)
+}
@@ -0,0 +1,279 @@
+package jirafinder
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gojira/ferry/config"
+	"github.com/pkg/errors"
+)
+
+// Columns computed from the status transitions of the changelog
+const (
+	LeadTimeColumn    = "lead time"
+	CycleTimeColumn   = "cycle time"
+	ReopenCountColumn = "reopen count"
+	// TimeInStatusPrefix starts the columns giving the time spent in a status, such as "time in Code Review"
+	TimeInStatusPrefix = "time in "
+)
+
+// statusCategory is the category of a status: new, indeterminate or done
+type statusCategory struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+// statusTransition is a change of the status of an issue
+type statusTransition struct {
+	At   time.Time
+	From string
+	To   string
+}
+
+// isFlowColumn tells if the column is computed from the status transitions
+func isFlowColumn(name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	switch name {
+	case LeadTimeColumn, CycleTimeColumn, ReopenCountColumn:
+		return true
+	}
+
+	return strings.HasPrefix(name, TimeInStatusPrefix) && len(name) > len(TimeInStatusPrefix)
+}
+
+// isFlowField tells if the column of the fields to retrieve is computed from the status transitions
+func (f *JiraFinder) isFlowField(name string) bool {
+	return containsFold(f.flowColumns, name)
+}
+
+// excludeJiraFields drops the flow columns named after a field of jira, such
+// as the "Time in Status" field of a marketplace app, which are retrieved instead
+func (f *JiraFinder) excludeJiraFields(fields []map[string]interface{}) {
+	columns := make([]string, 0, len(f.flowColumns))
+	for _, column := range f.flowColumns {
+		found := false
+		for _, field := range fields {
+			name, _ := field["name"].(string)
+			id, _ := field["id"].(string)
+			found = found || strings.EqualFold(name, strings.TrimSpace(column)) || strings.EqualFold(id, strings.TrimSpace(column))
+		}
+
+		if !found {
+			columns = append(columns, column)
+		}
+	}
+
+	f.flowColumns = columns
+}
+
+// flowColumns gives the flow columns of the fields to retrieve
+func flowColumns(c *config.Configuration) []string {
+	columns := make([]string, 0)
+	for _, name := range c.FieldNames() {
+		if isFlowColumn(name) {
+			columns = append(columns, name)
+		}
+	}
+
+	return columns
+}
+
+// validateFlow checks the flow section of the config
+func validateFlow(flow config.Flow) error {
+	switch strings.ToLower(flow.Unit) {
+	case "", FormatHours, FormatDays:
+		return nil
+	}
+
+	return errors.Errorf("unknown Unit '%s' of Flow, expected hours or days", flow.Unit)
+}
+
+// loadStatusCategories gets the category of every status of jira, keyed by the lower case status name
+func (f *JiraFinder) loadStatusCategories() error {
+	body, err := f.get("/rest/api/2/status", nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve statuses")
+	}
+
+	var statuses []struct {
+		Name           string         `json:"name"`
+		StatusCategory statusCategory `json:"statusCategory"`
+	}
+	if err := json.Unmarshal(body, &statuses); err != nil {
+		return errors.Wrap(err, "failed to parse statuses")
+	}
+
+	f.statusCategories = make(map[string]statusCategory, len(statuses))
+	for _, s := range statuses {
+		f.statusCategories[strings.ToLower(s.Name)] = s.StatusCategory
+	}
+
+	return nil
+}
+
+// inBoundary tells if the status is one of the statuses or status categories of the boundary
+func (f *JiraFinder) inBoundary(status string, boundary []string) bool {
+	if containsFold(boundary, status) {
+		return true
+	}
+
+	category, ok := f.statusCategories[strings.ToLower(status)]
+	return ok && (containsFold(boundary, category.Key) || containsFold(boundary, category.Name))
+}
+
+func (f *JiraFinder) startBoundary() []string {
+	if len(f.Config.Flow.Start) > 0 {
+		return f.Config.Flow.Start
+	}
+
+	return []string{"indeterminate"}
+}
+
+func (f *JiraFinder) doneBoundary() []string {
+	if len(f.Config.Flow.Done) > 0 {
+		return f.Config.Flow.Done
+	}
+
+	return []string{"done"}
+}
+
+// flowValues computes the flow columns of the issue fetched with its changelog,
+// the time in the current status runs until now
+func (f *JiraFinder) flowValues(issue map[string]interface{}, now time.Time) map[string]string {
+	fields, _ := issue["fields"].(map[string]interface{})
+	created, _ := fields["created"].(string)
+	resolved, _ := fields["resolutiondate"].(string)
+	current := getValueFromField(issue, "status")
+
+	transitions := statusTransitions(parseChangelog(issue))
+	start := parseJiraTime(created)
+	done := f.inBoundary(current, f.doneBoundary())
+
+	values := make(map[string]string, len(f.flowColumns))
+	for _, column := range f.flowColumns {
+		name := strings.ToLower(strings.TrimSpace(column))
+
+		switch name {
+		case LeadTimeColumn:
+			end := parseJiraTime(resolved)
+			if end.IsZero() && done {
+				end = f.lastTransitionTo(transitions, f.doneBoundary())
+			}
+			values[name] = f.formatDuration(start, end)
+
+		case CycleTimeColumn:
+			var end time.Time
+			if done {
+				end = f.lastTransitionTo(transitions, f.doneBoundary())
+			}
+			values[name] = f.formatDuration(f.firstTransitionTo(transitions, f.startBoundary()), end)
+
+		case ReopenCountColumn:
+			reopened := 0
+			for _, t := range transitions {
+				if f.inBoundary(t.From, f.doneBoundary()) && !f.inBoundary(t.To, f.doneBoundary()) {
+					reopened++
+				}
+			}
+			values[name] = strconv.Itoa(reopened)
+
+		default:
+			status := strings.TrimSpace(column[len(TimeInStatusPrefix):])
+			values[name] = f.formatHours(timeInStatus(transitions, status, start, current, now, f.duration).Hours())
+		}
+	}
+
+	return values
+}
+
+// statusTransitions gives the status changes of the histories, oldest first
+func statusTransitions(histories []history) []statusTransition {
+	transitions := make([]statusTransition, 0)
+	for _, h := range histories {
+		if change, ok := h.fieldChange("status"); ok {
+			transitions = append(transitions, statusTransition{At: h.Created, From: change.From, To: change.To})
+		}
+	}
+
+	return transitions
+}
+
+func (f *JiraFinder) firstTransitionTo(transitions []statusTransition, boundary []string) time.Time {
+	for _, t := range transitions {
+		if f.inBoundary(t.To, boundary) {
+			return t.At
+		}
+	}
+
+	return time.Time{}
+}
+
+func (f *JiraFinder) lastTransitionTo(transitions []statusTransition, boundary []string) time.Time {
+	for i := len(transitions) - 1; i >= 0; i-- {
+		if f.inBoundary(transitions[i].To, boundary) {
+			return transitions[i].At
+		}
+	}
+
+	return time.Time{}
+}
+
+// timeInStatus sums the time the issue spent in the status, from its creation
+// in the status left by the first transition until now in the current status
+func timeInStatus(transitions []statusTransition, status string, created time.Time, current string, now time.Time, duration func(time.Time, time.Time) time.Duration) time.Duration {
+	state := current
+	if len(transitions) > 0 {
+		state = transitions[0].From
+	}
+
+	total := time.Duration(0)
+	since := created
+	for _, t := range transitions {
+		if strings.EqualFold(state, status) && !since.IsZero() {
+			total += duration(since, t.At)
+		}
+		state, since = t.To, t.At
+	}
+
+	if strings.EqualFold(state, status) && !since.IsZero() {
+		total += duration(since, now)
+	}
+
+	return total
+}
+
+// formatDuration gives the time between the dates in the unit of the flow, N/A when one of them is missing
+func (f *JiraFinder) formatDuration(from time.Time, to time.Time) string {
+	if from.IsZero() || to.IsZero() || to.Before(from) {
+		return "N/A"
+	}
+
+	return f.formatHours(f.duration(from, to).Hours())
+}
+
+// duration gives the time between the dates, only counting the working hours when there is a calendar
+func (f *JiraFinder) duration(from time.Time, to time.Time) time.Duration {
+	if f.calendar != nil {
+		return f.calendar.workingTime(from, to)
+	}
+
+	return to.Sub(from)
+}
+
+// formatHours writes the hours in the unit of the flow, rounded to the hundredth,
+// a day being a working day when there is a calendar
+func (f *JiraFinder) formatHours(hours float64) string {
+	if strings.ToLower(f.Config.Flow.Unit) != FormatHours {
+		hoursPerDay := 24.0
+		if f.calendar != nil {
+			hoursPerDay = f.calendar.hoursPerDay()
+		}
+		hours /= hoursPerDay
+	}
+
+	return strconv.FormatFloat(math.Round(hours*100)/100, 'f', -1, 64)
+}
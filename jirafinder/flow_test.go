@@ -0,0 +1,143 @@
+package jirafinder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gojira/ferry/config"
+	"github.com/stretchr/testify/require"
+)
+
+func newFlowFinder(flow config.Flow, columns ...string) *JiraFinder {
+	return &JiraFinder{
+		Config:      config.Configuration{Flow: flow},
+		flowColumns: columns,
+		statusCategories: map[string]statusCategory{
+			"to do":       {Key: "new", Name: "To Do"},
+			"in progress": {Key: "indeterminate", Name: "In Progress"},
+			"in review":   {Key: "indeterminate", Name: "In Progress"},
+			"done":        {Key: "done", Name: "Done"},
+		},
+	}
+}
+
+// flowIssue is created on monday, worked from tuesday, reviewed, done on
+// thursday, reopened on friday and back in review since
+func flowIssue(resolved interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"fields": map[string]interface{}{
+			"created":        "2020-08-17T09:00:00.000+0000",
+			"resolutiondate": resolved,
+			"status":         map[string]interface{}{"name": "In Review"},
+		},
+		"changelog": map[string]interface{}{
+			"histories": []interface{}{
+				change("Ann", "2020-08-18T09:00:00.000+0000", item("status", "To Do", "In Progress")),
+				change("Ann", "2020-08-19T09:00:00.000+0000", item("status", "In Progress", "In Review")),
+				change("Bob", "2020-08-20T09:00:00.000+0000", item("status", "In Review", "Done")),
+				change("Bob", "2020-08-21T09:00:00.000+0000", item("status", "Done", "In Progress")),
+				change("Ann", "2020-08-21T21:00:00.000+0000", item("status", "In Progress", "In Review")),
+			},
+		},
+	}
+}
+
+func TestFlowValues(t *testing.T) {
+	r := require.New(t)
+	now := parseJiraTime("2020-08-22T09:00:00.000+0000")
+
+	f := newFlowFinder(config.Flow{}, "Lead Time", "cycle time", "reopen count", "time in In Progress", "Time in in review", "time in Blocked")
+	r.Equal(map[string]string{
+		"lead time":           "N/A",
+		"cycle time":          "N/A",
+		"reopen count":        "1",
+		"time in in progress": "1.5",
+		"time in in review":   "1.5",
+		"time in blocked":     "0",
+	}, f.flowValues(flowIssue(nil), now))
+
+	issue := flowIssue(nil)
+	issue["fields"].(map[string]interface{})["status"] = map[string]interface{}{"name": "Done"}
+	issue["changelog"].(map[string]interface{})["histories"] = append(issue["changelog"].(map[string]interface{})["histories"].([]interface{}),
+		change("Bob", "2020-08-22T09:00:00.000+0000", item("status", "In Review", "Done")))
+
+	f = newFlowFinder(config.Flow{Unit: "Hours"}, "lead time", "cycle time", "time in done")
+	r.Equal(map[string]string{
+		"lead time":    "120",
+		"cycle time":   "96",
+		"time in done": "25",
+	}, f.flowValues(issue, now.Add(time.Hour)))
+
+	issue["fields"].(map[string]interface{})["resolutiondate"] = "2020-08-22T21:00:00.000+0000"
+	r.Equal("132", f.flowValues(issue, now)["lead time"])
+}
+
+func TestFlowValues_Boundaries(t *testing.T) {
+	r := require.New(t)
+	now := parseJiraTime("2020-08-22T09:00:00.000+0000")
+
+	// the cycle starts with the review and ends when the issue is reviewed
+	f := newFlowFinder(config.Flow{Start: []string{"In Review"}, Done: []string{"In Review", "done"}}, "cycle time", "reopen count")
+	r.Equal(map[string]string{
+		"cycle time":   "2.5",
+		"reopen count": "1",
+	}, f.flowValues(flowIssue(nil), now))
+}
+
+func TestTimeInStatus_WithoutTransition(t *testing.T) {
+	f := newFlowFinder(config.Flow{})
+	created := parseJiraTime("2020-08-17T09:00:00.000+0000")
+	now := created.Add(36 * time.Hour)
+
+	require.Equal(t, 36*time.Hour, timeInStatus(nil, "to do", created, "To Do", now, f.duration))
+	require.Equal(t, time.Duration(0), timeInStatus(nil, "Done", created, "To Do", now, f.duration))
+}
+
+func TestIsFlowColumn(t *testing.T) {
+	r := require.New(t)
+
+	r.True(isFlowColumn("Cycle Time"))
+	r.True(isFlowColumn("time in Code Review"))
+	r.False(isFlowColumn("time in "))
+	r.False(isFlowColumn("timespent"))
+}
+
+func TestJiraFinder_FlowColumnNamedAfterField(t *testing.T) {
+	r := require.New(t)
+	err, c := config.New("../example_config/sample_for_test.json")
+	r.NoError(err)
+
+	c.FieldsToRetrieve = config.NewFields("key", "Time in Status", "time in review", "cycle time")
+	err, f := NewJiraFinder(c)
+	r.NoError(err)
+	r.Equal([]string{"Time in Status", "time in review", "cycle time"}, f.flowColumns)
+
+	fields := append([]map[string]interface{}{{"id": "customfield_10060", "name": "Time in Status", "custom": true}}, testFields...)
+	f.excludeJiraFields(fields)
+	r.Equal([]string{"time in review", "cycle time"}, f.flowColumns)
+
+	_, keys := f.processFields(fields)
+	r.Equal([]string{"key", "customfield_10060", "time in review", "cycle time"}, keys)
+}
+
+func TestJiraFinder_LoadStatusCategories(t *testing.T) {
+	r := require.New(t)
+	f := newStubFinder(t)
+
+	r.NoError(f.loadStatusCategories())
+	r.Equal(statusCategory{Key: "indeterminate", Name: "In Progress"}, f.statusCategories["in review"])
+	r.True(f.inBoundary("In Review", []string{"indeterminate"}))
+	r.True(f.inBoundary("done", []string{"Done"}))
+	r.False(f.inBoundary("To Do", []string{"indeterminate"}))
+}
+
+func TestJiraFinder_InvalidFlowUnit(t *testing.T) {
+	r := require.New(t)
+	err, c := config.New("../example_config/sample_for_test.json")
+	r.NoError(err)
+
+	c.Flow.Unit = "weeks"
+	err, _ = NewJiraFinder(c)
+	r.Error(err)
+	r.Contains(err.Error(), "unknown Unit 'weeks' of Flow")
+}
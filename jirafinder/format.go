@@ -0,0 +1,179 @@
+package jirafinder
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gojira/ferry/config"
+	"github.com/pkg/errors"
+)
+
+// Formats of the values of a column
+const (
+	FormatDate     = "date"
+	FormatDateTime = "datetime"
+	FormatNumber   = "number"
+	FormatHours    = "hours"
+	FormatDays     = "days"
+	FormatBoolean  = "boolean"
+)
+
+// Clean-ups of the values of a column
+const (
+	SanitizeCommas   = "commas"
+	SanitizeNewlines = "newlines"
+	SanitizeTrim     = "trim"
+)
+
+const (
+	defaultDateLayout     = "2006-01-02"
+	defaultDateTimeLayout = "2006-01-02 15:04:05"
+	defaultHoursPerDay    = 8
+	// createdLayout is the layout of the creation date when its field has no format
+	createdLayout = "02/Jan/06"
+)
+
+// lineBreaks replaces the line breaks of a value with spaces
+var lineBreaks = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ")
+
+// jiraDateLayouts are the layouts of the dates found in the issues
+var jiraDateLayouts = []string{
+	"02/Jan/06",
+	"2006-01-02T15:04:05.999-0700",
+	time.RFC3339,
+	"2006-01-02",
+}
+
+// validateFormats checks the formats and sanitize options of the fields to retrieve
+func validateFormats(fields []config.Field) error {
+	for _, field := range fields {
+		switch strings.ToLower(field.Format) {
+		case "", FormatDate, FormatDateTime, FormatNumber, FormatHours, FormatDays, FormatBoolean:
+		default:
+			return errors.Errorf("unknown format '%s' of field %s, expected date, datetime, number, hours, days or boolean", field.Format, field.Field)
+		}
+
+		if field.HoursPerDay < 0 {
+			return errors.Errorf("HoursPerDay of field %s must be positive", field.Field)
+		}
+
+		for _, option := range field.Sanitize {
+			switch strings.ToLower(option) {
+			case SanitizeCommas, SanitizeNewlines, SanitizeTrim:
+			default:
+				return errors.Errorf("unknown sanitize option '%s' of field %s, expected commas, newlines or trim", option, field.Field)
+			}
+		}
+	}
+
+	return nil
+}
+
+// formatColumns sanitizes the values of each column as asked by its field, then applies its format
+func formatColumns(columns [][]string, fields []config.Field) {
+	for i, values := range columns {
+		if i >= len(fields) {
+			continue
+		}
+
+		field := columnFormat(fields[i])
+		if field.Format == "" && len(field.Sanitize) == 0 {
+			continue
+		}
+
+		for j, v := range values {
+			values[j] = formatValue(sanitizeValue(v, field.Sanitize), field)
+		}
+	}
+}
+
+// columnFormat gives the field with its default format, the creation date
+// being written as 02/Jan/06 unless the field asks for another format
+func columnFormat(field config.Field) config.Field {
+	if field.Format == "" && strings.EqualFold(field.Field, "created") {
+		field.Format = FormatDate
+		if field.Layout == "" {
+			field.Layout = createdLayout
+		}
+	}
+
+	return field
+}
+
+// sanitizeValue applies the clean-ups in the given order, values are written
+// as is otherwise since every writer quotes them
+func sanitizeValue(value string, options []string) string {
+	for _, option := range options {
+		switch strings.ToLower(option) {
+		case SanitizeCommas:
+			value = strings.Replace(value, ",", "", -1)
+		case SanitizeNewlines:
+			value = lineBreaks.Replace(value)
+		case SanitizeTrim:
+			value = strings.TrimSpace(value)
+		}
+	}
+
+	return value
+}
+
+// formatValue formats a value, values that cannot be read in the format, such as N/A, are kept as is
+func formatValue(value string, field config.Field) string {
+	v := strings.TrimSpace(value)
+
+	// an unset flag is false
+	if strings.ToLower(field.Format) == FormatBoolean {
+		switch strings.ToLower(v) {
+		case "", "n/a", "false", "no", "0":
+			return "false"
+		}
+		return "true"
+	}
+
+	if v == "" || v == "N/A" {
+		return value
+	}
+
+	switch strings.ToLower(field.Format) {
+	case FormatDate, FormatDateTime:
+		layout := field.Layout
+		if layout == "" {
+			layout = defaultDateLayout
+			if strings.ToLower(field.Format) == FormatDateTime {
+				layout = defaultDateTimeLayout
+			}
+		}
+
+		for _, l := range jiraDateLayouts {
+			if t, err := time.Parse(l, v); err == nil {
+				return t.Format(layout)
+			}
+		}
+
+	case FormatNumber:
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return strconv.FormatFloat(n, 'f', -1, 64)
+		}
+
+	case FormatHours, FormatDays:
+		seconds, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return value
+		}
+
+		hours := seconds / 3600
+		if strings.ToLower(field.Format) == FormatHours {
+			return strconv.FormatFloat(math.Round(hours*100)/100, 'f', -1, 64)
+		}
+
+		hoursPerDay := field.HoursPerDay
+		if hoursPerDay == 0 {
+			hoursPerDay = defaultHoursPerDay
+		}
+		return strconv.FormatFloat(math.Round(hours/hoursPerDay*100)/100, 'f', -1, 64)
+	}
+
+	return value
+}
@@ -0,0 +1,91 @@
+package jirafinder
+
+import (
+	"testing"
+
+	"github.com/gojira/ferry/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		value string
+		field config.Field
+		want  string
+	}{
+		{"2020-09-01T10:15:30.000+0200", config.Field{Format: "date"}, "2020-09-01"},
+		{"2020-09-01T10:15:30.000+0200", config.Field{Format: "datetime"}, "2020-09-01 10:15:30"},
+		{"2020-09-01T10:15:30.000+0200", config.Field{Format: "date", Layout: "02/01/2006"}, "01/09/2020"},
+		{"01/Sep/20", config.Field{Format: "Date"}, "2020-09-01"},
+		{"N/A", config.Field{Format: "date"}, "N/A"},
+		{"soon", config.Field{Format: "date"}, "soon"},
+		{"5.0", config.Field{Format: "number"}, "5"},
+		{"0.5", config.Field{Format: "number"}, "0.5"},
+		{"5400", config.Field{Format: "hours"}, "1.5"},
+		{"57600", config.Field{Format: "days"}, "2"},
+		{"57600", config.Field{Format: "days", HoursPerDay: 6}, "2.67"},
+		{"true", config.Field{Format: "boolean"}, "true"},
+		{"Impediment", config.Field{Format: "boolean"}, "true"},
+		{"", config.Field{Format: "boolean"}, "false"},
+		{"N/A", config.Field{Format: "boolean"}, "false"},
+		{"5.0", config.Field{}, "5.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value+" "+tt.field.Format, func(t *testing.T) {
+			require.Equal(t, tt.want, formatValue(tt.value, tt.field))
+		})
+	}
+}
+
+func TestFormatColumns(t *testing.T) {
+	fields := []config.Field{{Field: "key"}, {Field: "timespent", Format: "hours"}}
+	columns := [][]string{{"POS-1"}, {"3600", "7200"}}
+
+	formatColumns(columns, fields)
+	require.Equal(t, [][]string{{"POS-1"}, {"1", "2"}}, columns)
+}
+
+func TestFormatColumns_Created(t *testing.T) {
+	issue := JiraIssue{
+		Data: map[string]interface{}{
+			"fields": map[string]interface{}{"created": "2020-09-01T10:15:30.000+0200"},
+		},
+		Fields: []string{"created", "created", "created", "created"},
+	}
+	fields := []config.Field{
+		{Field: "created"},
+		{Field: "created", Format: "datetime"},
+		{Field: "created", Format: "datetime", Layout: "02/01/2006 15:04"},
+		{Field: "created", Layout: "2006-01-02"},
+	}
+
+	columns := columnValues(issue)
+	formatColumns(columns, fields)
+	require.Equal(t, [][]string{{"01/Sep/20"}, {"2020-09-01 10:15:30"}, {"01/09/2020 10:15"}, {"2020-09-01"}}, columns)
+}
+
+func TestSanitizeValue(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal("Fix login, signup\n", sanitizeValue("Fix login, signup\n", nil))
+	r.Equal("Fix login signup\n", sanitizeValue("Fix login, signup\n", []string{"commas"}))
+	r.Equal("Steps: 1. login 2. signup ", sanitizeValue("Steps:\r\n1. login\n2. signup\r", []string{"Newlines"}))
+	r.Equal("Steps: login", sanitizeValue(" Steps:\nlogin\n", []string{"newlines", "trim"}))
+}
+
+func TestJiraFinder_InvalidFormat(t *testing.T) {
+	r := require.New(t)
+	err, c := config.New("../example_config/sample_for_test.json")
+	r.NoError(err)
+
+	c.FieldsToRetrieve = []config.Field{{Field: "created", Format: "time"}}
+	err, _ = NewJiraFinder(c)
+	r.Error(err)
+	r.Contains(err.Error(), "unknown format 'time' of field created")
+
+	c.FieldsToRetrieve = []config.Field{{Field: "summary", Sanitize: []string{"quotes"}}}
+	err, _ = NewJiraFinder(c)
+	r.Error(err)
+	r.Contains(err.Error(), "unknown sanitize option 'quotes' of field summary")
+}
@@ -5,17 +5,29 @@ import (
 	"fmt"
 	"github.com/gojira/ferry/config"
 	"github.com/pkg/errors"
-	"log"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	httprequest "github.com/gojira/ferry/httprequest"
+	"github.com/gojira/ferry/oauth"
+)
+
+const (
+	// searchPageSize is the number of issues requested per search API call
+	searchPageSize = 100
+	// defaultConcurrency is the default limit of in-flight requests to jira
+	defaultConcurrency = 10
+	// reorderWindow is the number of issues searched ahead of the last row written
+	reorderWindow = 2 * searchPageSize
+	// valueSeparator is the default separator of the values of multi-value fields
+	valueSeparator = ", "
 )
 
 type keyPairValue struct {
 	key   string
-	value string
+	value interface{}
 }
 
 type fieldParam struct {
@@ -31,10 +43,12 @@ type SearchResult struct {
 }
 
 type SubTask struct {
-	TaskType     string
-	AssigneeName string
-	TotalHours   string
-	Name         string
+	TaskType         string
+	AssigneeName     string
+	TotalHours       string
+	Name             string
+	EstimateSeconds  float64
+	TimeSpentSeconds float64
 }
 
 type JiraIssue struct {
@@ -42,6 +56,18 @@ type JiraIssue struct {
 	SubTasks     []SubTask
 	Fields       []string
 	AssigneeName string
+	IssueType    string
+	// Derived are the values of the derived fields and of the flow columns, keyed by their lower case name
+	Derived map[string]string
+	// Attribution is the name of the rule which found the AssigneeName
+	Attribution string
+
+	// changelog and created are the histories and creation date of the issue, oldest first
+	changelog []history
+	created   time.Time
+
+	// seq is the position of the issue in the search results
+	seq int
 }
 
 // JiraFinder finds the issue from jira based on the config
@@ -52,6 +78,20 @@ type JiraFinder struct {
 	fieldsCh  chan fieldParam
 	fieldKeys []string
 	mu        sync.RWMutex
+
+	// derived are the columns computed from the sub tasks
+	derived []derivedField
+	// attribution are the rules finding the developer of an issue in its changelog
+	attribution []config.AttributionRule
+	// flowColumns are the columns computed from the status transitions
+	flowColumns []string
+	// statusCategories are the categories of the statuses, keyed by their lower case name
+	statusCategories map[string]statusCategory
+	// calendar restricts the durations to the working hours, nil to count the calendar time
+	calendar *calendar
+
+	// requests limits the number of in-flight calls to the jira API
+	requests chan struct{}
 }
 
 func NewJiraFinderFomFile(configFile string) (error, *JiraFinder) {
@@ -69,16 +109,130 @@ func NewJiraFinder(c *config.Configuration) (error, *JiraFinder) {
 		return errors.New("no config file found. Set the config first before searching using SetConfig() func"), nil
 	}
 
-	return nil, &JiraFinder{
+	if err := validateJqlMode(c.JqlMode); err != nil {
+		return err, nil
+	}
+
+	if err := validateFilters(c.Filters); err != nil {
+		return err, nil
+	}
+
+	if err := validateFieldPaths(c.FieldNames()); err != nil {
+		return err, nil
+	}
+
+	if err := validateExplode(c); err != nil {
+		return err, nil
+	}
+
+	if err := validateFormats(c.FieldsToRetrieve); err != nil {
+		return err, nil
+	}
+
+	err, derived := compileDerivedFields(c.DerivedFields)
+	if err != nil {
+		return err, nil
+	}
+
+	err, attribution := compileAttribution(c.Attribution)
+	if err != nil {
+		return err, nil
+	}
+
+	if err := validateMode(c.Mode); err != nil {
+		return err, nil
+	}
+
+	if err := validateFlow(c.Flow); err != nil {
+		return err, nil
+	}
+
+	err, cal := newCalendar(c.Calendar)
+	if err != nil {
+		return err, nil
+	}
+
+	err, api := newClient(c)
+	if err != nil {
+		return err, nil
+	}
+
+	f := &JiraFinder{
 		Config: *c,
-		api:    httprequest.NewClient(c.JiraURL, c.AuthToken),
+		api:    api,
 
 		filtersCh: make(chan keyPairValue),
 		fieldsCh:  make(chan fieldParam),
 
 		fieldKeys: make([]string, len(c.FieldsToRetrieve)),
 		mu:        sync.RWMutex{},
+
+		requests: make(chan struct{}, concurrency(c)),
+
+		derived:     derived,
+		attribution: attribution,
+		flowColumns: flowColumns(c),
+		calendar:    cal,
+	}
+
+	// the slots are held per attempt, not while waiting to retry
+	api.InFlight = f.requests
+
+	// the days of the time tracking are working days of the calendar
+	if cal != nil {
+		f.Config.FieldsToRetrieve = make([]config.Field, len(c.FieldsToRetrieve))
+		for i, field := range c.FieldsToRetrieve {
+			if field.HoursPerDay == 0 {
+				field.HoursPerDay = cal.hoursPerDay()
+			}
+			f.Config.FieldsToRetrieve[i] = field
+		}
 	}
+
+	return nil, f
+}
+
+// newClient creates the jira API client with the authentication, retry policy and rate limit of the config
+func newClient(c *config.Configuration) (error, *httprequest.JiraClient) {
+	api := httprequest.NewClient(c.JiraURL, httprequest.TokenAuth{Scheme: c.AuthScheme, Token: c.AuthToken})
+
+	if c.Auth.Mode == config.AuthOAuth {
+		err, auth := oauth.NewAuthorizer(oauth.FromConfig(c))
+		if err != nil {
+			return err, nil
+		}
+
+		// 3LO apps reach jira through the Atlassian API gateway
+		api.URL = auth.APIURL()
+		api.Auth = auth
+	}
+
+	if c.Retry.MaxAttempts > 0 {
+		api.Retry.MaxAttempts = c.Retry.MaxAttempts
+	}
+
+	if c.Retry.Deadline != "" {
+		deadline, err := time.ParseDuration(c.Retry.Deadline)
+		if err != nil {
+			return errors.Wrapf(err, "invalid Retry.Deadline"), nil
+		}
+		api.Retry.MaxElapsed = deadline
+	}
+
+	if c.RateLimit.RequestsPerSecond > 0 {
+		api.Limiter = httprequest.NewRateLimiter(c.RateLimit.RequestsPerSecond, c.RateLimit.Burst)
+	}
+
+	return nil, api
+}
+
+// concurrency gives the configured limit of in-flight requests or the default one
+func concurrency(c *config.Configuration) int {
+	if c.Concurrency > 0 {
+		return c.Concurrency
+	}
+
+	return defaultConcurrency
 }
 
 // UseStub enforces usage of httptest
@@ -86,46 +240,200 @@ func (f *JiraFinder) UseStub() {
 	f.api.UseStub()
 }
 
-//Search finds the issue from jira based on the config
+//Search finds the issue from jira based on the config and streams them to the output file
 func (f *JiraFinder) Search() error {
-	output := [][]string{f.Config.FieldsToRetrieve}
-
 	err, out := f.produceFields()
 	if err != nil {
 		return err
 	}
 
+	err, savedJql := f.resolveSources()
+	if err != nil {
+		return err
+	}
+
+	// a field of jira wins over the flow column of the same name
+	f.excludeJiraFields(out)
+
+	// the flow columns need the status categories to find the cycle boundaries
+	if len(f.flowColumns) > 0 {
+		if err := f.loadStatusCategories(); err != nil {
+			return err
+		}
+	}
+
 	filters, fields := f.processFields(out)
-	err, response := f.search(filters, fields)
+	err, jql := f.buildJql(filters, customFieldClauses(out), savedJql)
+	if err != nil {
+		return err
+	}
+
+	err, w, file := NewOutputWriter(f.outputFormat(), f.Config.DownloadPath)
 	if err != nil {
 		return err
 	}
 
-	issues := f.prepareIssueObjects(response, fields)
-	issueCh := f.processIssues(issues)
+	// the previous export is only replaced by a complete one
+	fail := func(err error) error {
+		w.Close()
+		file.Discard()
+		return err
+	}
+
+	if cw, ok := w.(ColumnWriter); ok {
+		cw.SetColumns(f.columns())
+	}
+
+	if err := w.WriteHeader(f.header()); err != nil {
+		return fail(err)
+	}
+
+	// done stops the search and the workers when we return early
+	done := make(chan struct{})
+	defer close(done)
+
+	// window bounds the issues searched but not written yet, so that the rows
+	// waiting for a slow issue do not pile up
+	window := make(chan struct{}, reorderWindow)
+
+	issues, searchErr := f.search(jql, fields, window, done)
+	issueCh, processErr := f.processIssues(issues, done)
+
+	// the workers finish in any order, rows are written in the order of the search results
+	pending := make(map[int]*JiraIssue)
+	next := 0
+
+	for issueCh != nil {
+		select {
+		case i, open := <-issueCh:
+			if !open {
+				issueCh = nil
+				continue
+			}
+
+			pending[i.seq] = i
+			for issue, ok := pending[next]; ok; issue, ok = pending[next] {
+				if err := f.writeIssue(w, *issue); err != nil {
+					return fail(err)
+				}
+
+				delete(pending, next)
+				<-window
+				next++
+			}
+
+		case err := <-processErr:
+			return fail(err)
+		}
+	}
+
+	if err := firstError(processErr, searchErr); err != nil {
+		return fail(err)
+	}
+
+	if err := w.Close(); err != nil {
+		file.Discard()
+		return err
+	}
+
+	return file.Commit()
+}
+
+// outputFormat resolves the format from the config, falling back to the download path extension
+func (f *JiraFinder) outputFormat() string {
+	if f.Config.Format != "" {
+		return strings.ToLower(f.Config.Format)
+	}
+
+	if format := FormatFromPath(f.Config.DownloadPath); format != "" {
+		return format
+	}
+
+	return FormatCSV
+}
+
+// writeIssue writes the rows of the issue, into the sheet of its issue type when
+// requested, or its trace for the event logs
+func (f *JiraFinder) writeIssue(w OutputWriter, issue JiraIssue) error {
+	if lw, ok := w.(EventLogWriter); ok {
+		return lw.WriteTrace(f.trace(issue))
+	}
+
+	for _, row := range f.issueRows(issue) {
+		if sw, ok := w.(SheetWriter); ok && f.Config.SheetPerIssueType {
+			if err := sw.WriteSheetRow(issue.IssueType, row); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := w.WriteRow(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// issueRows gives the rows of the issue in the mode of the export
+func (f *JiraFinder) issueRows(issue JiraIssue) [][]string {
+	if f.mode() == ModeTransitions {
+		return f.transitionRows(issue)
+	}
+
+	columns := columnValues(issue)
+	formatColumns(columns, f.Config.FieldsToRetrieve)
+
+	return buildRows(columns, f.separator(), f.explodedColumns())
+}
+
+// separator gives the configured separator of the values of multi-value fields or the default one
+func (f *JiraFinder) separator() string {
+	if f.Config.MultiValueSeparator != "" {
+		return f.Config.MultiValueSeparator
+	}
+
+	return valueSeparator
+}
 
-	count := 0
-	for i := range issueCh {
-		if i != nil {
-			if f := download(*i); f != nil {
-				output = append(output, f)
+// explodedColumns tells for each field to retrieve if its values are written on separate rows
+func (f *JiraFinder) explodedColumns() []bool {
+	exploded := make([]bool, len(f.Config.FieldsToRetrieve))
+	for i, field := range f.Config.FieldsToRetrieve {
+		for _, e := range f.Config.Explode {
+			if strings.EqualFold(field.Field, e) || strings.EqualFold(field.Title(), e) {
+				exploded[i] = true
 			}
 		}
+	}
+
+	return exploded
+}
+
+// validateExplode checks that the exploded fields are retrieved
+func validateExplode(c *config.Configuration) error {
+	for _, e := range c.Explode {
+		found := false
+		for _, field := range c.FieldsToRetrieve {
+			found = found || strings.EqualFold(field.Field, e) || strings.EqualFold(field.Title(), e)
+		}
 
-		count++
-		if count == response.Total {
-			close(issueCh)
+		if !found {
+			return errors.Errorf("cannot explode '%s', it is not in FieldsToRetrieve", e)
 		}
 	}
 
-	return writeToCsv(output, f.Config.DownloadPath)
+	return nil
 }
 
 func (f *JiraFinder) produceFields() (error, []map[string]interface{}) {
-	body := f.api.Get("/rest/api/2/field", nil)
+	body, err := f.get("/rest/api/2/field", nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve fields"), nil
+	}
 
 	var fields []map[string]interface{}
-	err := json.Unmarshal(body, &fields)
+	err = json.Unmarshal(body, &fields)
 	if err != nil {
 		return errors.Wrap(err, "failed to build fields"), nil
 	}
@@ -133,46 +441,84 @@ func (f *JiraFinder) produceFields() (error, []map[string]interface{}) {
 	return nil, fields
 }
 
-func (f *JiraFinder) collectParams(kpDestination map[string]string) {
-	for {
+// collectParams gathers the filters and fields resolved by processFields until
+// both channels are closed, then closes collected
+func (f *JiraFinder) collectParams(kpDestination map[string]interface{}, collected chan<- struct{}) {
+	defer close(collected)
+
+	filtersCh, fieldsCh := f.filtersCh, f.fieldsCh
+	for filtersCh != nil || fieldsCh != nil {
 		select {
-		case kv, open := <-f.filtersCh:
-			if open {
-				kpDestination[kv.key] = kv.value
+		case kv, open := <-filtersCh:
+			if !open {
+				filtersCh = nil
+				continue
 			}
+			kpDestination[kv.key] = kv.value
 
-		case fp, open := <-f.fieldsCh:
-			if open && fp.name != "" {
+		case fp, open := <-fieldsCh:
+			if !open {
+				fieldsCh = nil
+				continue
+			}
+			if fp.name != "" {
 				f.addField(fp)
 			}
 		}
 	}
 }
 
-func (f *JiraFinder) processFields(fields []map[string]interface{}) (map[string]string, []string) {
+func (f *JiraFinder) processFields(fields []map[string]interface{}) (map[string]interface{}, []string) {
 
-	filters := make(map[string]string)
+	filters := make(map[string]interface{})
+	collected := make(chan struct{})
 
 	var wg sync.WaitGroup
 	wg.Add(len(fields))
 
-	go f.collectParams(filters)
+	go f.collectParams(filters, collected)
+
+	// a custom field named after a system field is left to the system one
+	system := make(map[string]bool)
+	names := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		names[strings.ToLower(field["name"].(string))] = true
+		if !field["custom"].(bool) {
+			system[strings.ToLower(field["name"].(string))] = true
+		}
+	}
 
 	for _, field := range fields {
 		go func(field map[string]interface{}) {
 			defer wg.Done()
 
+			shadowed := field["custom"].(bool) && system[strings.ToLower(field["name"].(string))]
+
 			for k, v := range f.Config.Filters {
-				if strings.ToLower(field["name"].(string)) == strings.ToLower(k) {
-					key := k
-					if field["custom"].(bool) {
-						key = "cf[" + strings.Replace(field["id"].(string), "customfield_", "", -1) + "]"
-					}
-					f.filtersCh <- keyPairValue{key, v.(string)}
+				if key, ok := filterKey(field, k); ok {
+					f.filtersCh <- keyPairValue{key, v}
 				}
 			}
 
-			for i, v := range f.Config.FieldsToRetrieve {
+			for i, v := range f.Config.FieldNames() {
+				if shadowed {
+					break
+				}
+
+				// the computed columns are not retrieved
+				if f.isComputedField(v) {
+					continue
+				}
+
+				// a path starts with the name of a field, which is replaced with its id,
+				// names holding a dot such as "Dev. Estimate" are fields rather than paths
+				if head, rest := splitFieldPath(v); rest != "" && !names[strings.ToLower(v)] {
+					if strings.ToLower(field["name"].(string)) == strings.ToLower(head) {
+						f.fieldsCh <- fieldParam{i, field["id"].(string) + rest}
+					}
+					continue
+				}
+
 				if strings.ToLower(field["name"].(string)) == strings.ToLower(v) {
 					val := v
 					if field["custom"].(bool) {
@@ -188,8 +534,17 @@ func (f *JiraFinder) processFields(fields []map[string]interface{}) (map[string]
 
 	close(f.filtersCh)
 	close(f.fieldsCh)
+	<-collected
 	clean(filters)
 
+	// fields not matching a field name are field ids, keys of the issue such as
+	// key, or the columns computed from the sub tasks
+	for i, v := range f.Config.FieldNames() {
+		if f.fieldKeys[i] == "" {
+			f.fieldKeys[i] = v
+		}
+	}
+
 	return filters, f.fieldKeys
 }
 
@@ -204,48 +559,81 @@ func (f *JiraFinder) setFields(params map[string]string) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
+	// only the field starting a path is requested
+	keys := make([]string, 0, len(f.fieldKeys))
+	for _, key := range f.fieldKeys {
+		head, _ := splitFieldPath(key)
+		keys = append(keys, head)
+	}
+
 	// prevent data race
-	params["fields"] = strings.Join(f.fieldKeys, ",")
+	params["fields"] = strings.Join(keys, ",")
 }
 
-func (f *JiraFinder) search(filters map[string]string, fields []string) (error, *SearchResult) {
-	var step int64 = 100
-	var startAt int64 = 0
+// search pages through the search API and sends every issue found, taking a
+// slot of window for each of them. The returned error channel receives the
+// outcome once all pages are sent
+func (f *JiraFinder) search(jql string, fields []string, window chan<- struct{}, done <-chan struct{}) (<-chan JiraIssue, <-chan error) {
+	issues := make(chan JiraIssue, searchPageSize)
+	errCh := make(chan error, 1)
+
 	params := make(map[string]string)
-	params["jql"] = getJql(filters)
-	params["maxResults"] = strconv.FormatInt(step, 10)
-	params["startAt"] = strconv.FormatInt(startAt, 10)
+	params["jql"] = jql
+	params["maxResults"] = strconv.Itoa(searchPageSize)
 	f.setFields(params)
 
-	err, result := f.doSearchByParams(params)
-	if err != nil {
-		return err, nil
-	}
+	go func() {
+		defer close(issues)
 
-	// handle results over the limit of 100
-	for {
-		if result.Total <= len(result.Issues) {
-			break
-		}
+		startAt, seq := 0, 0
+		for {
+			params["startAt"] = strconv.Itoa(startAt)
 
-		startAt += step
-		params["startAt"] = strconv.FormatInt(startAt, 10)
+			err, result := f.doSearchByParams(params)
+			if err != nil {
+				errCh <- err
+				return
+			}
 
-		err, r := f.doSearchByParams(params)
-		if err != nil {
-			return err, nil
+			for _, issue := range f.prepareIssueObjects(result, fields) {
+				issue.seq = seq
+				seq++
+
+				select {
+				case window <- struct{}{}:
+				case <-done:
+					errCh <- nil
+					return
+				}
+
+				select {
+				case issues <- issue:
+				case <-done:
+					errCh <- nil
+					return
+				}
+			}
+
+			// handle results over the page size
+			startAt += len(result.Issues)
+			if len(result.Issues) == 0 || startAt >= result.Total {
+				break
+			}
 		}
 
-		result.Issues = append(result.Issues, r.Issues...)
-	}
+		errCh <- nil
+	}()
 
-	return nil, result
+	return issues, errCh
 }
 
 func (f *JiraFinder) doSearchByParams(params map[string]string) (error, *SearchResult) {
 	result := new(SearchResult)
 
-	body := f.api.Get("/rest/api/2/search", params)
+	body, err := f.get("/rest/api/2/search", params)
+	if err != nil {
+		return errors.Wrap(err, "failed to search issues"), nil
+	}
 
 	if err := json.Unmarshal(body, &result); err != nil {
 		return errors.Wrapf(err, "failed to parse search API response"), nil
@@ -265,45 +653,131 @@ func (f *JiraFinder) prepareIssueObjects(result *SearchResult, fields []string)
 	return ji
 }
 
-func (f *JiraFinder) processIssues(issues []JiraIssue) chan *JiraIssue {
+// processIssues enriches the issues with their sub tasks and changelog using
+// one worker per allowed in-flight request, the first failure is sent to the
+// returned error channel
+func (f *JiraFinder) processIssues(issues <-chan JiraIssue, done <-chan struct{}) (<-chan *JiraIssue, <-chan error) {
+	workers := cap(f.requests)
+	out := make(chan *JiraIssue, workers)
+	errCh := make(chan error, 1)
 
-	out := make(chan *JiraIssue, 100)
-	for i, issue := range issues {
-		go func(issue JiraIssue, i int) {
-			issueID := issue.Data["id"].(string)
-			err, parent := f.getIssue(issueID, true)
+	var wg sync.WaitGroup
+	wg.Add(workers)
 
-			if err != nil {
-				log.Printf("error while processing issue %s: %s", issueID, err)
-				out <- nil
-				return
+	for n := 0; n < workers; n++ {
+		go func() {
+			defer wg.Done()
+
+			for issue := range issues {
+				err, enriched := f.processIssue(issue)
+				if err != nil {
+					select {
+					case errCh <- errors.Wrapf(err, "failed to process issue %s", issue.Data["key"]):
+					default:
+					}
+					return
+				}
+
+				select {
+				case out <- enriched:
+				case <-done:
+					return
+				}
 			}
+		}()
+	}
 
-			subTasks := parent["fields"].(map[string]interface{})["subtasks"].([]interface{})
-			result := make([]SubTask, 0)
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
 
-			for _, v := range subTasks {
-				_, subTaskIssue := f.getIssue(v.(map[string]interface{})["id"].(string), false)
-				assignee := getValueFromField(subTaskIssue, "assignee")
-				issueType := getValueFromField(subTaskIssue, "issuetype")
-				name := getValueFromField(subTaskIssue, "summary")
-				totalHours := getValueFromField(subTaskIssue, "timetracking")
-				currentSubTask := SubTask{TaskType: issueType, Name: name, AssigneeName: assignee, TotalHours: totalHours}
+	return out, errCh
+}
 
-				result = append(result, currentSubTask)
+// firstError returns the first error already sent to one of the channels, it does not block
+func firstError(channels ...<-chan error) error {
+	for _, ch := range channels {
+		select {
+		case err := <-ch:
+			if err != nil {
+				return err
 			}
+		default:
+		}
+	}
 
-			issue.SubTasks = result
+	return nil
+}
 
-			parentIssueType := getValueFromField(parent, "issuetype")
-			if isBug(parentIssueType) {
-				issue.AssigneeName = getDeveloperNameFromLog(parent)
-			}
-			out <- &issue
-		}(issue, i)
+func (f *JiraFinder) processIssue(issue JiraIssue) (error, *JiraIssue) {
+	issueID := issue.Data["id"].(string)
+	err, parent := f.getIssue(issueID, true)
+	if err != nil {
+		return err, nil
+	}
+
+	// the sub tasks are only fetched for the derived fields written
+	var subTasks []interface{}
+	if f.needsSubTasks() {
+		subTasks, _ = parent["fields"].(map[string]interface{})["subtasks"].([]interface{})
 	}
+	result := make([]SubTask, 0)
 
-	return out
+	for _, v := range subTasks {
+		err, subTaskIssue := f.getIssue(v.(map[string]interface{})["id"].(string), false)
+		if err != nil {
+			return err, nil
+		}
+		assignee := getValueFromField(subTaskIssue, "assignee")
+		issueType := getValueFromField(subTaskIssue, "issuetype")
+		name := getValueFromField(subTaskIssue, "summary")
+		totalHours := getValueFromField(subTaskIssue, "timetracking")
+		currentSubTask := SubTask{
+			TaskType:         issueType,
+			Name:             name,
+			AssigneeName:     assignee,
+			TotalHours:       totalHours,
+			EstimateSeconds:  timeTrackingSeconds(subTaskIssue, "originalEstimateSeconds"),
+			TimeSpentSeconds: timeTrackingSeconds(subTaskIssue, "timeSpentSeconds"),
+		}
+
+		result = append(result, currentSubTask)
+	}
+
+	issue.SubTasks = result
+	issue.Derived = deriveValues(f.derived, result)
+	if len(f.flowColumns) > 0 {
+		for name, value := range f.flowValues(parent, time.Now()) {
+			issue.Derived[name] = value
+		}
+	}
+
+	parentIssueType := getValueFromField(parent, "issuetype")
+	issue.IssueType = parentIssueType
+	issue.Attribution, issue.AssigneeName = attribute(f.attribution, parent, parentIssueType)
+
+	parentFields, _ := parent["fields"].(map[string]interface{})
+	created, _ := parentFields["created"].(string)
+	issue.changelog, issue.created = parseChangelog(parent), parseJiraTime(created)
+
+	return nil, &issue
+}
+
+// needsSubTasks tells if a column written by the mode is derived from the sub tasks
+func (f *JiraFinder) needsSubTasks() bool {
+	for _, column := range f.columns() {
+		if f.isDerivedField(column.Field) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// get calls the jira API, the client waiting for a free slot when the concurrency limit is reached
+func (f *JiraFinder) get(path string, params map[string]string) ([]byte, error) {
+	return f.api.Get(path, params)
 }
 
 func (f *JiraFinder) getIssue(issueID string, includeChangeLog bool) (error, map[string]interface{}) {
@@ -316,7 +790,10 @@ func (f *JiraFinder) getIssue(issueID string, includeChangeLog bool) (error, map
 		getIssueURL += "?expand=changelog"
 	}
 
-	body := f.api.Get(getIssueURL, nil)
+	body, err := f.get(getIssueURL, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to retrieve issue %s", issueID), nil
+	}
 
 	if err := json.Unmarshal(body, &responseResult); err != nil {
 		return errors.Wrapf(err, "failed to retrieve issue"), responseResult
@@ -325,21 +802,57 @@ func (f *JiraFinder) getIssue(issueID string, includeChangeLog bool) (error, map
 	return nil, responseResult
 }
 
+// download gives the row of the issue, the values of multi-value fields being joined
 func download(issue JiraIssue) []string {
-	fieldValues := make([]string, 0)
+	return buildRows(columnValues(issue), valueSeparator, nil)[0]
+}
+
+// columnValues gives the values of every field of the issue, several for the multi-value fields
+func columnValues(issue JiraIssue) [][]string {
+	columns := make([][]string, 0, len(issue.Fields))
 
 	// Listen to final populated issue and prepare the output for all the fields mentioned in the configuration
 	for _, field := range issue.Fields {
-		val, ok := issue.Data[field]
-		if ok {
-			fieldValues = append(fieldValues, strings.Replace(val.(string), ",", "", -1))
+		var values []string
+		if isFieldPath(field) {
+			values = getPathValues(issue.Data, field)
+		} else if val, ok := issue.Data[field]; ok {
+			values = []string{getValue(val, field)}
 		} else {
-			fieldValues = append(fieldValues, getFieldValue(field, issue))
+			values = getFieldValues(field, issue)
 		}
+
+		if len(values) == 0 {
+			values = []string{""}
+		}
+		columns = append(columns, values)
 	}
-	if len(fieldValues) > 0 {
-		return fieldValues
+
+	return columns
+}
+
+// buildRows joins the values of each column with the separator, the exploded
+// columns give one row per value instead, combined with the other exploded ones
+func buildRows(columns [][]string, separator string, exploded []bool) [][]string {
+	rows := [][]string{make([]string, 0, len(columns))}
+
+	for i, values := range columns {
+		if i < len(exploded) && exploded[i] {
+			next := make([][]string, 0, len(rows)*len(values))
+			for _, row := range rows {
+				for _, v := range values {
+					next = append(next, append(append(make([]string, 0, len(columns)), row...), v))
+				}
+			}
+			rows = next
+			continue
+		}
+
+		value := strings.Join(values, separator)
+		for j := range rows {
+			rows[j] = append(rows[j], value)
+		}
 	}
 
-	return []string{}
+	return rows
 }
@@ -1,9 +1,22 @@
 package jirafinder
 
 import (
+	"fmt"
+	"github.com/gojira/ferry/config"
+	"github.com/gojira/ferry/httprequest"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestJiraFinder_DownloadIssue(t *testing.T) {
@@ -64,3 +77,367 @@ func TestJiraFinder_Search(t *testing.T) {
 	err = f.Search()
 	r.NoErrorf(err, "search func resulting to error: %s", err)
 }
+
+func TestJiraFinder_SearchAllPages(t *testing.T) {
+	r := require.New(t)
+	err, f := NewJiraFinderFomFile("../example_config/sample_for_test.json")
+	r.NoErrorf(err, "instantiation resulting to error: '%s'", err)
+
+	dir, err := ioutil.TempDir("", "ferry")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+
+	f.Config.DownloadPath = filepath.Join(dir, "issues.csv")
+	f.UseStub()
+
+	err = f.Search()
+	r.NoErrorf(err, "search func resulting to error: %s", err)
+
+	content, err := ioutil.ReadFile(f.Config.DownloadPath)
+	r.NoError(err)
+
+	// the stub reports 6 issues, served 2 per page
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	r.Len(lines, 7, "expected header and 6 rows")
+	r.Equal("key,summary,assignee,scrum team", lines[0])
+}
+
+func TestJiraFinder_SearchConcurrencyLimit(t *testing.T) {
+	r := require.New(t)
+	err, c := config.New("../example_config/sample_for_test.json")
+	r.NoError(err)
+
+	dir, err := ioutil.TempDir("", "ferry")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+
+	c.DownloadPath = filepath.Join(dir, "issues.csv")
+	c.Concurrency = 2
+
+	err, f := NewJiraFinder(c)
+	r.NoError(err)
+	f.UseStub()
+
+	// count in-flight requests in front of the stub
+	var inFlight, maxInFlight int32
+	stub := f.api.URL
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			m := atomic.LoadInt32(&maxInFlight)
+			if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+				break
+			}
+		}
+
+		time.Sleep(5 * time.Millisecond)
+		resp, err := http.Get(stub + req.RequestURI)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		io.Copy(w, resp.Body)
+	}))
+	defer proxy.Close()
+	f.api.URL = proxy.URL
+
+	err = f.Search()
+	r.NoErrorf(err, "search func resulting to error: %s", err)
+	r.True(maxInFlight <= 2, "expected at most 2 requests in flight, got %d", maxInFlight)
+}
+
+func TestJiraFinder_SearchAuthError(t *testing.T) {
+	r := require.New(t)
+	err, f := NewJiraFinderFomFile("../example_config/sample_for_test.json")
+	r.NoError(err)
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer api.Close()
+	f.api.URL = api.URL
+
+	err = f.Search()
+	r.Error(err)
+
+	var authErr *httprequest.AuthError
+	r.True(errors.As(err, &authErr), "expected an AuthError, got %s", err)
+}
+
+func TestJiraFinder_SearchErrorKeepsPreviousExport(t *testing.T) {
+	r := require.New(t)
+	err, f := NewJiraFinderFomFile("../example_config/sample_for_test.json")
+	r.NoError(err)
+	f.UseStub()
+
+	dir, err := ioutil.TempDir("", "ferry")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+
+	f.Config.DownloadPath = filepath.Join(dir, "issues.csv")
+	r.NoError(ioutil.WriteFile(f.Config.DownloadPath, []byte("previous export\n"), 0644))
+
+	// the search is rejected once the fields are resolved and the output created
+	stub := f.api.URL
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasPrefix(req.URL.Path, "/rest/api/2/search") {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errorMessages": ["Error in the JQL Query"]}`))
+			return
+		}
+
+		resp, err := http.Get(stub + req.RequestURI)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		io.Copy(w, resp.Body)
+	}))
+	defer proxy.Close()
+	f.api.URL = proxy.URL
+
+	r.Error(f.Search())
+
+	content, err := ioutil.ReadFile(f.Config.DownloadPath)
+	r.NoError(err)
+	r.Equal("previous export\n", string(content))
+
+	files, err := ioutil.ReadDir(dir)
+	r.NoError(err)
+	r.Len(files, 1, "the temporary file is removed")
+}
+
+func TestJiraFinder_RetryConfig(t *testing.T) {
+	r := require.New(t)
+	err, c := config.New("../example_config/sample_for_test.json")
+	r.NoError(err)
+
+	c.Retry = config.Retry{MaxAttempts: 6, Deadline: "90s"}
+	err, f := NewJiraFinder(c)
+	r.NoError(err)
+	r.Equal(6, f.api.Retry.MaxAttempts)
+	r.Equal(90*time.Second, f.api.Retry.MaxElapsed)
+
+	c.Retry.Deadline = "soon"
+	err, _ = NewJiraFinder(c)
+	r.Error(err)
+	r.Contains(err.Error(), "invalid Retry.Deadline")
+}
+
+func TestJiraFinder_SearchStructuredFilters(t *testing.T) {
+	r := require.New(t)
+	err, c := config.New("../example_config/sample_for_test.json")
+	r.NoError(err)
+
+	dir, err := ioutil.TempDir("", "ferry")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+
+	c.DownloadPath = filepath.Join(dir, "issues.csv")
+	c.Filters = map[string]interface{}{
+		"Project":     "POS",
+		"Sprint":      map[string]interface{}{"in": []interface{}{"Sprint 1", "Sprint 2"}},
+		"Created":     map[string]interface{}{"gte": "-30d"},
+		"Fix Version": map[string]interface{}{"is": "EMPTY"},
+	}
+
+	err, f := NewJiraFinder(c)
+	r.NoError(err)
+
+	jql, stop := useRecordingStub(f)
+	defer stop()
+
+	err = f.Search()
+	r.NoErrorf(err, "search func resulting to error: %s", err)
+
+	r.Equal(`cf[10020] in ("Sprint 1", "Sprint 2") AND created >= "-30d" AND fixVersion is EMPTY AND project = "POS"`, jql.Load())
+}
+
+func TestJiraFinder_SearchKeepsOrder(t *testing.T) {
+	r := require.New(t)
+	err, c := config.New("../example_config/sample_for_test.json")
+	r.NoError(err)
+
+	dir, err := ioutil.TempDir("", "ferry")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+
+	c.DownloadPath = filepath.Join(dir, "issues.csv")
+	c.Filters = nil
+	c.FieldsToRetrieve = config.NewFields("key", "summary")
+	c.OrderBy = "created DESC"
+	c.Concurrency = 4
+
+	const total = 12
+	var jql atomic.Value
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.URL.Path == "/rest/api/2/field":
+			w.Write([]byte(`[{"id":"key","name":"Key","custom":false},{"id":"summary","name":"Summary","custom":false}]`))
+
+		case req.URL.Path == "/rest/api/2/search":
+			jql.Store(req.URL.Query().Get("jql"))
+
+			// pages of 5 issues, whatever the page size asked
+			var startAt int
+			fmt.Sscan(req.URL.Query().Get("startAt"), &startAt)
+			issues := make([]string, 0)
+			for n := startAt + 1; n <= total && n <= startAt+5; n++ {
+				issues = append(issues, fmt.Sprintf(`{"id":"%d","key":"POS-%d","fields":{"summary":"issue %d"}}`, n, n, n))
+			}
+			fmt.Fprintf(w, `{"startAt":%d,"total":%d,"issues":[%s]}`, startAt, total, strings.Join(issues, ","))
+
+		case strings.HasPrefix(req.URL.Path, "/rest/api/2/issue/"):
+			// the first issues are the slowest to enrich
+			var n int
+			fmt.Sscan(strings.TrimPrefix(req.URL.Path, "/rest/api/2/issue/"), &n)
+			time.Sleep(time.Duration(total-n) * 3 * time.Millisecond)
+			w.Write([]byte(`{"fields":{"subtasks":[],"issuetype":{"name":"Story"}}}`))
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer api.Close()
+
+	err, f := NewJiraFinder(c)
+	r.NoError(err)
+	f.api.URL = api.URL
+
+	err = f.Search()
+	r.NoErrorf(err, "search func resulting to error: %s", err)
+	r.Equal("ORDER BY created DESC", jql.Load())
+
+	content, err := ioutil.ReadFile(c.DownloadPath)
+	r.NoError(err)
+
+	expected := []string{"key,summary"}
+	for n := 1; n <= total; n++ {
+		expected = append(expected, fmt.Sprintf("POS-%d,issue %d", n, n))
+	}
+	r.Equal(expected, strings.Split(strings.TrimSpace(string(content)), "\n"))
+}
+
+// useRecordingStub serves the stub behind a proxy recording the query of the last search
+func useRecordingStub(f *JiraFinder) (*atomic.Value, func()) {
+	f.UseStub()
+
+	jql := new(atomic.Value)
+	jql.Store("")
+
+	stub := f.api.URL
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasPrefix(req.URL.Path, "/rest/api/2/search") {
+			jql.Store(req.URL.Query().Get("jql"))
+		}
+
+		resp, err := http.Get(stub + req.RequestURI)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		io.Copy(w, resp.Body)
+	}))
+	f.api.URL = proxy.URL
+
+	return jql, proxy.Close
+}
+
+func TestBuildRows(t *testing.T) {
+	r := assert.New(t)
+	columns := [][]string{{"POS-7"}, {"backend", "urgent"}, {"1.0", "1.1"}}
+
+	r.Equal([][]string{{"POS-7", "backend | urgent", "1.0 | 1.1"}}, buildRows(columns, " | ", nil))
+
+	r.Equal([][]string{
+		{"POS-7", "backend", "1.0, 1.1"},
+		{"POS-7", "urgent", "1.0, 1.1"},
+	}, buildRows(columns, ", ", []bool{false, true, false}))
+
+	r.Equal([][]string{
+		{"POS-7", "backend", "1.0"},
+		{"POS-7", "backend", "1.1"},
+		{"POS-7", "urgent", "1.0"},
+		{"POS-7", "urgent", "1.1"},
+	}, buildRows(columns, ", ", []bool{false, true, true}))
+
+	r.Equal([][]string{{"POS-7", "", "1.0"}}, buildRows([][]string{{"POS-7"}, {""}, {"1.0"}}, ", ", []bool{false, true, false}))
+}
+
+func TestJiraFinder_WriteIssueExplode(t *testing.T) {
+	r := require.New(t)
+	err, c := config.New("../example_config/sample_for_test.json")
+	r.NoError(err)
+
+	c.FieldsToRetrieve = config.NewFields("key", "labels", "fixVersions[*].name")
+	c.Explode = []string{"Labels"}
+	c.MultiValueSeparator = "; "
+	err, f := NewJiraFinder(c)
+	r.NoError(err)
+
+	out := &bufferCloser{}
+	err, w := newWriter(FormatCSV, out)
+	r.NoError(err)
+
+	issue := JiraIssue{
+		Data: map[string]interface{}{
+			"key": "POS-7",
+			"fields": map[string]interface{}{
+				"labels":      []interface{}{"backend", "urgent"},
+				"fixVersions": []interface{}{map[string]interface{}{"name": "1.0"}, map[string]interface{}{"name": "1.1"}},
+			},
+		},
+		Fields: []string{"key", "labels", "fixVersions[*].name"},
+	}
+	r.NoError(f.writeIssue(w, issue))
+	r.NoError(w.Close())
+	r.Equal("POS-7,backend,1.0; 1.1\nPOS-7,urgent,1.0; 1.1\n", out.String())
+
+	c.FieldsToRetrieve[1].Header = "Tags"
+	c.Explode = []string{"Tags"}
+	err, f = NewJiraFinder(c)
+	r.NoError(err)
+	r.Equal([]bool{false, true, false}, f.explodedColumns())
+
+	c.Explode = []string{"components"}
+	err, _ = NewJiraFinder(c)
+	r.Error(err)
+	r.Contains(err.Error(), "cannot explode 'components'")
+}
+
+func TestJiraFinder_WriteIssueKeepsValues(t *testing.T) {
+	r := require.New(t)
+	err, c := config.New("../example_config/sample_for_test.json")
+	r.NoError(err)
+
+	c.FieldsToRetrieve = []config.Field{
+		{Field: "key"},
+		{Field: "summary"},
+		{Field: "description"},
+		{Field: "summary", Header: "Clean summary", Sanitize: []string{"commas"}},
+	}
+	err, f := NewJiraFinder(c)
+	r.NoError(err)
+
+	out := &bufferCloser{}
+	err, w := newWriter(FormatCSV, out)
+	r.NoError(err)
+
+	issue := JiraIssue{
+		Data: map[string]interface{}{
+			"key": "POS-7",
+			"fields": map[string]interface{}{
+				"summary":     "Fix login, signup and reset",
+				"description": "Steps:\n1. \"login\"",
+			},
+		},
+		Fields: []string{"key", "summary", "description", "summary"},
+	}
+	r.NoError(f.writeIssue(w, issue))
+	r.NoError(w.Close())
+	r.Equal("POS-7,\"Fix login, signup and reset\",\"Steps:\n1. \"\"login\"\"\",Fix login signup and reset\n", out.String())
+}
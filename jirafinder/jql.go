@@ -0,0 +1,693 @@
+package jirafinder
+
+import (
+	"encoding/csv"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// Modes combining the raw Jql of the config with the Filters
+const (
+	// JqlAnd requires the issues to match both the Filters and the Jql
+	JqlAnd = "and"
+	// JqlReplace ignores the Filters and only uses the Jql
+	JqlReplace = "replace"
+)
+
+const (
+	tokenSpace = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenPunct
+)
+
+// jqlToken is a lexical element of a JQL query, joining the text of all the
+// tokens gives back the query
+type jqlToken struct {
+	kind int
+	text string
+}
+
+// fieldFollowers are the keywords that can follow a field name in a clause
+var fieldFollowers = map[string]bool{
+	"in":      true,
+	"not":     true,
+	"is":      true,
+	"was":     true,
+	"changed": true,
+}
+
+// filterOperators are the operators of the structured filters and their JQL,
+// in the order their clauses are written
+var filterOperators = []struct {
+	name string
+	jql  string
+}{
+	{"eq", "="},
+	{"ne", "!="},
+	{"in", "in"},
+	{"not_in", "not in"},
+	{"gt", ">"},
+	{"gte", ">="},
+	{"lt", "<"},
+	{"lte", "<="},
+	{"contains", "~"},
+	{"not_contains", "!~"},
+	{"is", "is"},
+	{"is_not", "is not"},
+}
+
+var (
+	// jqlFunctionCall matches a call such as openSprints() or startOfMonth(-1), the name being checked against jqlFunctions
+	jqlFunctionCall = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_]*)\(.*\)$`)
+	// jqlIdentifier matches the field names that can be written without quotes
+	jqlIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+	// jqlCustomField matches the cf[id] clause of a custom field
+	jqlCustomField = regexp.MustCompile(`^cf\[[0-9]+\]$`)
+	// orderByPrefix matches the ORDER BY keywords written in front of the OrderBy of the config
+	orderByPrefix = regexp.MustCompile(`(?i)^order\s+by\s+`)
+)
+
+// jqlReserved are the reserved words of JQL, they must be quoted to be used as names
+var jqlReserved = map[string]bool{}
+
+// jqlFunctions are the functions of JQL, keyed by their lower case name, a
+// value calling one of them is kept as is rather than quoted
+var jqlFunctions = map[string]bool{}
+
+func init() {
+	words := `a an abort access add after alias all alter and any are as asc at audit avg be before begin
+		between boolean break but by byte catch cf char character check checkpoint collate collation column
+		commit connect continue count create current date decimal declare decrement default defaults define
+		delete delimiter desc difference distinct divide do double drop else empty encoding end equals escape
+		exclusive exec execute exists explain false fetch file field first float for from function go goto
+		grant greater group having identified if immediate in increment index initial inner inout input insert
+		int integer intersect intersection into is isempty isnull join last left less like limit lock long max
+		min minus mode modify modulo more multiply next noaudit not notin nowait null number object of on option
+		or order outer output power previous prior privileges public raise raw remainder rename resource return
+		returns revoke right row rowid rownum rows select session set share size sqrt start strict string
+		subtract sum synonym table then to trans transaction trigger true uid union unique update user validate
+		values view when whenever where while with`
+
+	for _, w := range strings.Fields(words) {
+		jqlReserved[w] = true
+	}
+
+	functions := `approved approver cascadeOption closedSprints componentsLeadByUser currentLogin currentUser
+		earliestUnreleasedVersion endOfDay endOfMonth endOfWeek endOfYear futureSprints issueHistory
+		issuesWithRemoteLinksByGlobalId lastLogin latestReleasedVersion linkedIssues membersOf myApproval
+		myPending now openSprints pending pendingBy projectsLeadByUser projectsWhereUserHasPermission
+		projectsWhereUserHasRole releasedVersions standardIssueTypes startOfDay startOfMonth startOfWeek
+		startOfYear subtaskIssueTypes unreleasedVersions updatedBy votedIssues watchedIssues`
+
+	for _, f := range strings.Fields(functions) {
+		jqlFunctions[strings.ToLower(f)] = true
+	}
+}
+
+// getJql ANDs the clauses of the resolved filters, sorted by field so that
+// the same filters always give the same query
+func getJql(filters map[string]interface{}) (error, string) {
+	clauses := make([]string, 0, len(filters))
+	for _, k := range sortedKeys(filters) {
+		err, c := filterClauses(k, filters[k])
+		if err != nil {
+			return err, ""
+		}
+		clauses = append(clauses, c...)
+	}
+
+	return nil, strings.Join(clauses, " AND ")
+}
+
+// validateFilters checks the values of the Filters before searching
+func validateFilters(filters map[string]interface{}) error {
+	for _, k := range sortedKeys(filters) {
+		if err, _ := filterClauses(k, filters[k]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sortedKeys(filters map[string]interface{}) []string {
+	keys := make([]string, 0, len(filters))
+	for k := range filters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// filterKey tells if the filter named name applies to the field, giving the
+// key used in the JQL: cf[id] for the custom fields, the clause name otherwise
+func filterKey(field map[string]interface{}, name string) (string, bool) {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.Replace(s, " ", "", -1))
+	}
+
+	fieldName, _ := field["name"].(string)
+	clauseNames, _ := field["clauseNames"].([]interface{})
+
+	matches := normalize(fieldName) == normalize(name)
+	for _, clause := range clauseNames {
+		if c, ok := clause.(string); ok && normalize(c) == normalize(name) {
+			matches = true
+		}
+	}
+
+	if !matches {
+		return "", false
+	}
+
+	if custom, _ := field["custom"].(bool); custom {
+		id, _ := field["id"].(string)
+		return "cf[" + strings.Replace(id, "customfield_", "", -1) + "]", true
+	}
+
+	if len(clauseNames) > 0 {
+		if c, ok := clauseNames[0].(string); ok && c != "" {
+			return c, true
+		}
+	}
+
+	return name, true
+}
+
+// filterClauses renders the value of a filter: a string is matched as is, or
+// as a list when it holds commas, a json array is matched as a list and an
+// object gives one clause per operator
+func filterClauses(key string, value interface{}) (error, []string) {
+	key = quoteJqlField(key)
+
+	switch v := value.(type) {
+	case string, float64, bool, []interface{}:
+		return operatorClause(key, "in", v)
+
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return errors.Errorf("filter %s has no operator", key), nil
+		}
+
+		for op := range v {
+			if !isFilterOperator(op) {
+				return errors.Errorf("unknown operator '%s' in filter %s, expected one of %s", op, key, filterOperatorNames()), nil
+			}
+		}
+
+		clauses := make([]string, 0, len(v))
+		for _, op := range filterOperators {
+			val, ok := v[op.name]
+			if !ok {
+				continue
+			}
+
+			err, c := operatorClause(key, op.name, val)
+			if err != nil {
+				return err, nil
+			}
+			clauses = append(clauses, c...)
+		}
+
+		return nil, clauses
+	}
+
+	return errors.Errorf("unsupported value for filter %s, expected a string, an array or an object of operators", key), nil
+}
+
+// operatorClause renders the clause of one operator of a filter
+func operatorClause(key string, op string, value interface{}) (error, []string) {
+	jql := ""
+	for _, o := range filterOperators {
+		if o.name == op {
+			jql = o.jql
+		}
+	}
+
+	switch op {
+	case "in", "not_in":
+		err, values := listLiterals(key, value)
+		if err != nil {
+			return err, nil
+		}
+
+		// a single value keeps the simpler equality of the former filters
+		if len(values) == 1 {
+			if op == "in" {
+				return nil, []string{key + " = " + values[0]}
+			}
+			return nil, []string{key + " != " + values[0]}
+		}
+
+		return nil, []string{key + " " + jql + " (" + strings.Join(values, ", ") + ")"}
+
+	case "is", "is_not":
+		s, _ := value.(string)
+		s = strings.ToUpper(strings.TrimSpace(s))
+		if s != "EMPTY" && s != "NULL" {
+			return errors.Errorf("filter %s: %s expects EMPTY or NULL", key, op), nil
+		}
+
+		return nil, []string{key + " " + jql + " " + s}
+	}
+
+	err, literal := scalarLiteral(value)
+	if err != nil {
+		return errors.Wrapf(err, "filter %s: %s", key, op), nil
+	}
+
+	return nil, []string{key + " " + jql + " " + literal}
+}
+
+// listLiterals renders the values of in and not_in, given as a json array or
+// as a comma separated string, where a value holding a comma is double quoted
+func listLiterals(key string, value interface{}) (error, []string) {
+	var values []interface{}
+	switch v := value.(type) {
+	case []interface{}:
+		values = v
+	case string:
+		for _, s := range splitFilterList(v) {
+			values = append(values, s)
+		}
+	default:
+		values = []interface{}{v}
+	}
+
+	if len(values) == 0 {
+		return errors.Errorf("filter %s has an empty list", key), nil
+	}
+
+	literals := make([]string, 0, len(values))
+	for _, v := range values {
+		err, literal := scalarLiteral(v)
+		if err != nil {
+			return errors.Wrapf(err, "filter %s", key), nil
+		}
+		literals = append(literals, literal)
+	}
+
+	return nil, literals
+}
+
+// splitFilterList splits a comma separated string such as
+// `Open, "Payments, EU"`, the double quotes keeping the commas of a value
+func splitFilterList(list string) []string {
+	reader := csv.NewReader(strings.NewReader(list))
+	reader.TrimLeadingSpace = true
+	reader.LazyQuotes = true
+
+	values, err := reader.Read()
+	if err != nil {
+		return []string{list}
+	}
+
+	for i, v := range values {
+		values[i] = strings.TrimSpace(v)
+	}
+
+	return values
+}
+
+// scalarLiteral renders a single value of a filter, calls of JQL functions are kept as is
+func scalarLiteral(value interface{}) (error, string) {
+	switch v := value.(type) {
+	case string:
+		v = strings.TrimSpace(v)
+		if isJqlFunctionCall(v) {
+			return nil, v
+		}
+		return nil, quoteJql(v)
+
+	case float64:
+		return nil, strconv.FormatFloat(v, 'f', -1, 64)
+
+	case bool:
+		return nil, quoteJql(strconv.FormatBool(v))
+	}
+
+	return errors.Errorf("unsupported value %v", value), ""
+}
+
+// isJqlFunctionCall tells if the value calls a known JQL function, such as
+// openSprints(), rather than being a name such as API(v2)
+func isJqlFunctionCall(value string) bool {
+	m := jqlFunctionCall.FindStringSubmatch(value)
+	return m != nil && jqlFunctions[strings.ToLower(m[1])]
+}
+
+// quoteJql encodes a value as a JQL string literal, escaping the quotes,
+// backslashes and control characters it holds
+func quoteJql(value string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if unicode.IsControl(r) {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+
+	return b.String()
+}
+
+// quoteJqlField keeps the cf[id] and the plain field names as they are, the
+// names holding spaces or special characters and the reserved words are quoted
+func quoteJqlField(name string) string {
+	if jqlCustomField.MatchString(name) {
+		return name
+	}
+
+	if jqlIdentifier.MatchString(name) && !jqlReserved[strings.ToLower(name)] {
+		return name
+	}
+
+	return quoteJql(name)
+}
+
+func isFilterOperator(name string) bool {
+	for _, op := range filterOperators {
+		if op.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func filterOperatorNames() string {
+	names := make([]string, 0, len(filterOperators))
+	for _, op := range filterOperators {
+		names = append(names, op.name)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// validateJqlMode checks the mode combining the Jql with the Filters
+func validateJqlMode(mode string) error {
+	switch strings.ToLower(mode) {
+	case "", JqlAnd, JqlReplace:
+		return nil
+	}
+
+	return errors.Errorf("unknown JqlMode '%s', expected and or replace", mode)
+}
+
+// buildJql gives the query of the search, made of the Filters, the query of
+// the saved filter and the raw Jql of the config according to its JqlMode,
+// sorted by the OrderBy of the config
+func (f *JiraFinder) buildJql(filters map[string]interface{}, customFields map[string]string, savedJql string) (error, string) {
+	savedJql = strings.TrimSpace(savedJql)
+	raw := strings.TrimSpace(f.Config.Jql)
+	if raw != "" {
+		raw = resolveJqlFields(raw, customFields)
+	}
+
+	jql := ""
+	if (raw != "" || savedJql != "") && strings.ToLower(f.Config.JqlMode) == JqlReplace {
+		jql = andJql("", savedJql, raw)
+	} else {
+		err, filtersJql := getJql(filters)
+		if err != nil {
+			return err, ""
+		}
+
+		jql = andJql(filtersJql, savedJql, raw)
+	}
+
+	return nil, withOrderBy(jql, f.Config.OrderBy, customFields)
+}
+
+// withOrderBy replaces the ORDER BY clause of the query with the given one
+func withOrderBy(jql string, orderBy string, customFields map[string]string) string {
+	orderBy = orderByPrefix.ReplaceAllString(strings.TrimSpace(orderBy), "")
+	if orderBy == "" {
+		return jql
+	}
+
+	where, _ := splitOrderBy(jql)
+
+	return strings.TrimSpace(where + " " + resolveJqlFields("ORDER BY "+orderBy, customFields))
+}
+
+// andJql requires the filters and all the raw queries to match, keeping the
+// ORDER BY of the last raw query having one at the end
+func andJql(filtersJql string, raws ...string) string {
+	conditions := make([]string, 0, len(raws)+1)
+	if filtersJql != "" {
+		conditions = append(conditions, filtersJql)
+	}
+
+	orderBy := ""
+	for _, raw := range raws {
+		where, order := splitOrderBy(raw)
+		if where != "" {
+			conditions = append(conditions, where)
+		}
+		if order != "" {
+			orderBy = order
+		}
+	}
+
+	// the raw queries may hold OR, keep their precedence when they are combined
+	if len(conditions) > 1 {
+		for i := range conditions {
+			if i > 0 || filtersJql == "" {
+				conditions[i] = "(" + conditions[i] + ")"
+			}
+		}
+	}
+
+	return strings.TrimSpace(strings.Join(conditions, " AND ") + " " + orderBy)
+}
+
+// splitOrderBy separates the ORDER BY clause from the conditions of the query
+func splitOrderBy(jql string) (string, string) {
+	tokens := tokenizeJql(jql)
+	if i := orderByIndex(tokens); i >= 0 {
+		return strings.TrimSpace(joinTokens(tokens[:i])), strings.TrimSpace(joinTokens(tokens[i:]))
+	}
+
+	return strings.TrimSpace(jql), ""
+}
+
+// customFieldClauses maps the lower case names of the custom fields to their
+// cf[id] clause, names shared with a system field are left to the system one
+func customFieldClauses(fields []map[string]interface{}) map[string]string {
+	clauses := make(map[string]string)
+	system := make(map[string]bool)
+
+	for _, field := range fields {
+		name, _ := field["name"].(string)
+		id, _ := field["id"].(string)
+		if name == "" {
+			continue
+		}
+
+		if custom, _ := field["custom"].(bool); custom {
+			clauses[strings.ToLower(name)] = "cf[" + strings.Replace(id, "customfield_", "", -1) + "]"
+		} else {
+			system[strings.ToLower(name)] = true
+		}
+	}
+
+	for name := range system {
+		delete(clauses, name)
+	}
+
+	return clauses
+}
+
+// resolveJqlFields replaces the names of the custom fields used in the
+// clauses and in the ORDER BY of the query with their cf[id] clause
+func resolveJqlFields(jql string, customFields map[string]string) string {
+	if len(customFields) == 0 {
+		return jql
+	}
+
+	tokens := tokenizeJql(jql)
+	orderBy := orderByIndex(tokens)
+
+	for i, token := range tokens {
+		if token.kind != tokenWord && token.kind != tokenString {
+			continue
+		}
+
+		if !isFieldToken(tokens, i, orderBy) {
+			continue
+		}
+
+		if clause, ok := customFields[strings.ToLower(unquoteJql(token))]; ok {
+			tokens[i].text = clause
+		}
+	}
+
+	return joinTokens(tokens)
+}
+
+// isFieldToken tells if the word or string at index i names a field, either
+// followed by an operator or listed in the ORDER BY clause
+func isFieldToken(tokens []jqlToken, i int, orderBy int) bool {
+	if orderBy >= 0 && i > orderBy+2 {
+		prev := previousToken(tokens, i)
+		return prev.kind == tokenPunct && prev.text == "," || prev.kind == tokenWord && strings.EqualFold(prev.text, "by")
+	}
+
+	next := nextToken(tokens, i)
+	switch next.kind {
+	case tokenOperator:
+		return true
+	case tokenWord:
+		return fieldFollowers[strings.ToLower(next.text)]
+	}
+
+	return false
+}
+
+// orderByIndex gives the index of the ORDER keyword starting the ORDER BY clause, -1 when missing
+func orderByIndex(tokens []jqlToken) int {
+	for i, token := range tokens {
+		if token.kind != tokenWord || !strings.EqualFold(token.text, "order") {
+			continue
+		}
+
+		if next := nextToken(tokens, i); next.kind == tokenWord && strings.EqualFold(next.text, "by") {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func nextToken(tokens []jqlToken, i int) jqlToken {
+	for j := i + 1; j < len(tokens); j++ {
+		if tokens[j].kind != tokenSpace {
+			return tokens[j]
+		}
+	}
+
+	return jqlToken{kind: tokenSpace}
+}
+
+func previousToken(tokens []jqlToken, i int) jqlToken {
+	for j := i - 1; j >= 0; j-- {
+		if tokens[j].kind != tokenSpace {
+			return tokens[j]
+		}
+	}
+
+	return jqlToken{kind: tokenSpace}
+}
+
+func joinTokens(tokens []jqlToken) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteString(token.text)
+	}
+
+	return b.String()
+}
+
+// unquoteJql gives the value of a string token, or the text of a word
+func unquoteJql(token jqlToken) string {
+	if token.kind != tokenString || len(token.text) < 2 {
+		return token.text
+	}
+
+	quote := token.text[0]
+	text := token.text[1:]
+	if text[len(text)-1] == quote {
+		text = text[:len(text)-1]
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' && i+1 < len(text) {
+			i++
+		}
+		b.WriteByte(text[i])
+	}
+
+	return b.String()
+}
+
+// tokenizeJql splits the query into words, quoted strings, operators,
+// parentheses and commas, and the spaces in between
+func tokenizeJql(jql string) []jqlToken {
+	tokens := make([]jqlToken, 0)
+
+	isSpace := func(c byte) bool { return c == ' ' || c == '\t' || c == '\n' || c == '\r' }
+	isOperator := func(c byte) bool { return strings.IndexByte("=!~<>", c) >= 0 }
+	isPunct := func(c byte) bool { return strings.IndexByte("(),", c) >= 0 }
+	isQuote := func(c byte) bool { return c == '"' || c == '\'' }
+
+	for i := 0; i < len(jql); {
+		start := i
+		c := jql[i]
+
+		switch {
+		case isSpace(c):
+			for i < len(jql) && isSpace(jql[i]) {
+				i++
+			}
+			tokens = append(tokens, jqlToken{tokenSpace, jql[start:i]})
+
+		case isQuote(c):
+			i++
+			for i < len(jql) && jql[i] != c {
+				if jql[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i < len(jql) {
+				i++
+			} else {
+				i = len(jql)
+			}
+			tokens = append(tokens, jqlToken{tokenString, jql[start:i]})
+
+		case isOperator(c):
+			for i < len(jql) && isOperator(jql[i]) {
+				i++
+			}
+			tokens = append(tokens, jqlToken{tokenOperator, jql[start:i]})
+
+		case isPunct(c):
+			i++
+			tokens = append(tokens, jqlToken{tokenPunct, jql[start:i]})
+
+		default:
+			for i < len(jql) && !isSpace(jql[i]) && !isOperator(jql[i]) && !isPunct(jql[i]) && !isQuote(jql[i]) {
+				i++
+			}
+			tokens = append(tokens, jqlToken{tokenWord, jql[start:i]})
+		}
+	}
+
+	return tokens
+}
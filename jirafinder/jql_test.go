@@ -0,0 +1,407 @@
+package jirafinder
+
+import (
+	"testing"
+
+	"github.com/gojira/ferry/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testFields = []map[string]interface{}{
+	{"id": "status", "name": "Status", "custom": false},
+	{"id": "customfield_10020", "name": "Sprint", "custom": true},
+	{"id": "customfield_10022", "name": "Target start", "custom": true},
+	{"id": "customfield_10030", "name": "Story Points", "custom": true},
+	{"id": "customfield_10040", "name": "Status", "custom": true},
+}
+
+func TestCustomFieldClauses(t *testing.T) {
+	r := assert.New(t)
+
+	clauses := customFieldClauses(testFields)
+	r.Equal(map[string]string{
+		"sprint":       "cf[10020]",
+		"target start": "cf[10022]",
+		"story points": "cf[10030]",
+	}, clauses, "custom fields named after a system field should be left out")
+}
+
+func TestResolveJqlFields(t *testing.T) {
+	clauses := customFieldClauses(testFields)
+
+	tests := []struct {
+		name string
+		jql  string
+		want string
+	}{
+		{
+			name: "bare name",
+			jql:  "Sprint in openSprints()",
+			want: "cf[10020] in openSprints()",
+		},
+		{
+			name: "quoted name",
+			jql:  `"Story Points" >= 5 AND 'target start' <= -7d`,
+			want: "cf[10030] >= 5 AND cf[10022] <= -7d",
+		},
+		{
+			name: "values are kept",
+			jql:  `project = POS AND summary ~ "Sprint" AND labels in (Sprint, "Story Points")`,
+			want: `project = POS AND summary ~ "Sprint" AND labels in (Sprint, "Story Points")`,
+		},
+		{
+			name: "keywords after the field",
+			jql:  `NOT sprint is EMPTY OR "Story Points" not in (1,2) OR Sprint was "Sprint 1" OR Sprint changed`,
+			want: `NOT cf[10020] is EMPTY OR cf[10030] not in (1,2) OR cf[10020] was "Sprint 1" OR cf[10020] changed`,
+		},
+		{
+			name: "order by",
+			jql:  `status = Done order by "Story Points" DESC, Sprint, created`,
+			want: `status = Done order by cf[10030] DESC, cf[10020], created`,
+		},
+		{
+			name: "system fields and ids",
+			jql:  `Status != Done AND cf[10020] = 3`,
+			want: `Status != Done AND cf[10020] = 3`,
+		},
+		{
+			name: "escaped quote",
+			jql:  `summary ~ "a \"quoted\" Sprint" AND Sprint = 1`,
+			want: `summary ~ "a \"quoted\" Sprint" AND cf[10020] = 1`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, resolveJqlFields(tt.jql, clauses))
+		})
+	}
+}
+
+func TestSplitOrderBy(t *testing.T) {
+	r := assert.New(t)
+
+	where, orderBy := splitOrderBy("status = Done ORDER BY created DESC")
+	r.Equal("status = Done", where)
+	r.Equal("ORDER BY created DESC", orderBy)
+
+	where, orderBy = splitOrderBy(`summary ~ "order by" `)
+	r.Equal(`summary ~ "order by"`, where)
+	r.Equal("", orderBy)
+
+	where, orderBy = splitOrderBy("order by rank")
+	r.Equal("", where)
+	r.Equal("order by rank", orderBy)
+}
+
+func TestJiraFinder_BuildJql(t *testing.T) {
+	filters := map[string]interface{}{"project": "POS"}
+	clauses := customFieldClauses(testFields)
+
+	tests := []struct {
+		name    string
+		filters map[string]interface{}
+		jql     string
+		mode    string
+		orderBy string
+		saved   string
+		want    string
+	}{
+		{
+			name:    "filters only",
+			filters: filters,
+			want:    `project = "POS"`,
+		},
+		{
+			name:    "and",
+			filters: filters,
+			jql:     "Sprint in openSprints() OR updated >= -7d ORDER BY rank",
+			want:    `project = "POS" AND (cf[10020] in openSprints() OR updated >= -7d) ORDER BY rank`,
+		},
+		{
+			name:    "and without filters",
+			filters: map[string]interface{}{},
+			jql:     "updated >= -7d",
+			mode:    JqlAnd,
+			want:    "updated >= -7d",
+		},
+		{
+			name:    "and with order by only",
+			filters: filters,
+			jql:     "ORDER BY created",
+			want:    `project = "POS" ORDER BY created`,
+		},
+		{
+			name:    "replace",
+			filters: filters,
+			jql:     "Sprint in openSprints()",
+			mode:    "Replace",
+			want:    "cf[10020] in openSprints()",
+		},
+		{
+			name:    "order by",
+			filters: filters,
+			orderBy: "rank ASC",
+			want:    `project = "POS" ORDER BY rank ASC`,
+		},
+		{
+			name:    "order by replaces the one of the jql",
+			filters: filters,
+			jql:     "updated >= -7d order by created",
+			orderBy: `ORDER BY "Story Points" DESC, created`,
+			want:    `project = "POS" AND (updated >= -7d) ORDER BY cf[10030] DESC, created`,
+		},
+		{
+			name:    "saved filter",
+			filters: filters,
+			saved:   "assignee = currentUser() OR reporter = currentUser() ORDER BY Rank ASC",
+			jql:     "Sprint in openSprints()",
+			want:    `project = "POS" AND (assignee = currentUser() OR reporter = currentUser()) AND (cf[10020] in openSprints()) ORDER BY Rank ASC`,
+		},
+		{
+			name:    "saved filter replacing the filters",
+			filters: filters,
+			saved:   "assignee = currentUser() OR reporter = currentUser()",
+			jql:     "updated >= -7d ORDER BY created",
+			mode:    JqlReplace,
+			want:    `(assignee = currentUser() OR reporter = currentUser()) AND (updated >= -7d) ORDER BY created`,
+		},
+		{
+			name:    "saved filter only",
+			filters: map[string]interface{}{},
+			saved:   "assignee = currentUser() OR reporter = currentUser()",
+			want:    `assignee = currentUser() OR reporter = currentUser()`,
+		},
+		{
+			name:    "order by without filters",
+			filters: map[string]interface{}{},
+			orderBy: "created DESC",
+			want:    "ORDER BY created DESC",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &JiraFinder{Config: config.Configuration{Jql: tt.jql, JqlMode: tt.mode, OrderBy: tt.orderBy}}
+			err, jql := f.buildJql(tt.filters, clauses, tt.saved)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, jql)
+		})
+	}
+}
+
+func TestJiraFinder_InvalidJqlMode(t *testing.T) {
+	r := require.New(t)
+	err, c := config.New("../example_config/sample_for_test.json")
+	r.NoError(err)
+
+	c.JqlMode = "or"
+	err, _ = NewJiraFinder(c)
+	r.Error(err)
+	r.Contains(err.Error(), "unknown JqlMode 'or'")
+}
+
+func TestFilterClauses(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  []string
+	}{
+		{
+			name:  "string",
+			value: "Sprint 1",
+			want:  []string{`status = "Sprint 1"`},
+		},
+		{
+			name:  "comma separated string",
+			value: "Open, In Progress",
+			want:  []string{`status in ("Open", "In Progress")`},
+		},
+		{
+			name:  "quoted value keeps commas",
+			value: `"Payments, EU", Core`,
+			want:  []string{`status in ("Payments, EU", "Core")`},
+		},
+		{
+			name:  "quoted single value",
+			value: `"Payments, EU"`,
+			want:  []string{`status = "Payments, EU"`},
+		},
+		{
+			name:  "name shaped as a function",
+			value: "API(v2)",
+			want:  []string{`status = "API(v2)"`},
+		},
+		{
+			name:  "function in list",
+			value: map[string]interface{}{"in": []interface{}{"currentUser()", "membersOf(qa)"}},
+			want:  []string{`status in (currentUser(), membersOf(qa))`},
+		},
+		{
+			name:  "number",
+			value: float64(3),
+			want:  []string{"status = 3"},
+		},
+		{
+			name:  "not in",
+			value: map[string]interface{}{"not_in": []interface{}{"Done", "Closed"}},
+			want:  []string{`status not in ("Done", "Closed")`},
+		},
+		{
+			name:  "range",
+			value: map[string]interface{}{"lt": "-7d", "gte": "-30d"},
+			want:  []string{`status >= "-30d"`, `status < "-7d"`},
+		},
+		{
+			name:  "function",
+			value: map[string]interface{}{"gte": "startOfMonth(-1)"},
+			want:  []string{"status >= startOfMonth(-1)"},
+		},
+		{
+			name:  "empty",
+			value: map[string]interface{}{"is": "empty"},
+			want:  []string{"status is EMPTY"},
+		},
+		{
+			name:  "not empty",
+			value: map[string]interface{}{"is_not": "NULL"},
+			want:  []string{"status is not NULL"},
+		},
+		{
+			name:  "text search",
+			value: map[string]interface{}{"contains": "login", "not_contains": "sso"},
+			want:  []string{`status ~ "login"`, `status !~ "sso"`},
+		},
+		{
+			name:  "quotes",
+			value: map[string]interface{}{"ne": `Team O'Neil "core"`},
+			want:  []string{`status != "Team O'Neil \"core\""`},
+		},
+		{
+			name:  "array keeps commas",
+			value: []interface{}{"Payments, EU", "Core"},
+			want:  []string{`status in ("Payments, EU", "Core")`},
+		},
+		{
+			name:  "single value array",
+			value: []interface{}{"Payments, EU"},
+			want:  []string{`status = "Payments, EU"`},
+		},
+		{
+			name:  "reserved words",
+			value: map[string]interface{}{"in": []interface{}{"empty", "order", "null"}},
+			want:  []string{`status in ("empty", "order", "null")`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err, clauses := filterClauses("status", tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, clauses)
+		})
+	}
+}
+
+func TestValidateFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters map[string]interface{}
+		err     string
+	}{
+		{"unknown operator", map[string]interface{}{"Status": map[string]interface{}{"nin": "Done"}}, "unknown operator 'nin' in filter Status"},
+		{"no operator", map[string]interface{}{"Status": map[string]interface{}{}}, "filter Status has no operator"},
+		{"is", map[string]interface{}{"Fix Version": map[string]interface{}{"is": "1.0"}}, "is expects EMPTY or NULL"},
+		{"empty list", map[string]interface{}{"Status": map[string]interface{}{"in": []interface{}{}}}, "filter Status has an empty list"},
+		{"object in array", map[string]interface{}{"Status": []interface{}{map[string]interface{}{}}}, "filter Status: unsupported value"},
+		{"null", map[string]interface{}{"Status": nil}, "unsupported value for filter Status"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateFilters(tt.filters)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestFilterKey(t *testing.T) {
+	r := assert.New(t)
+
+	fixVersions := map[string]interface{}{"id": "fixVersions", "name": "Fix versions", "custom": false, "clauseNames": []interface{}{"fixVersion"}}
+	sprint := map[string]interface{}{"id": "customfield_10020", "name": "Sprint", "custom": true, "clauseNames": []interface{}{"cf[10020]", "Sprint"}}
+
+	key, ok := filterKey(fixVersions, "Fix Version")
+	r.True(ok)
+	r.Equal("fixVersion", key)
+
+	key, ok = filterKey(sprint, "sprint")
+	r.True(ok)
+	r.Equal("cf[10020]", key)
+
+	_, ok = filterKey(sprint, "Fix Version")
+	r.False(ok)
+}
+
+func TestQuoteJql(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"Sprint 1", `"Sprint 1"`},
+		{"Team O'Neil", `"Team O'Neil"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`C:\temp`, `"C:\\temp"`},
+		{"two\nlines\tand\rtabs", `"two\nlines\tand\rtabs"`},
+		{"bell\a", `"bell\u0007"`},
+		{"", `""`},
+		{"Zürich", `"Zürich"`},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, quoteJql(tt.value), "quoting %q", tt.value)
+	}
+}
+
+func TestQuoteJqlField(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"project", "project"},
+		{"fixVersion", "fixVersion"},
+		{"cf[10020]", "cf[10020]"},
+		{"Story Points", `"Story Points"`},
+		{"Order", `"Order"`},
+		{"team-name", `"team-name"`},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, quoteJqlField(tt.name), "quoting %q", tt.name)
+	}
+}
+
+func TestGetJql_QuotedFieldNames(t *testing.T) {
+	err, jql := getJql(map[string]interface{}{"Story Points": map[string]interface{}{"gte": float64(5)}})
+	require.NoError(t, err)
+	assert.Equal(t, `"Story Points" >= 5`, jql)
+}
+
+func TestGetJql_Deterministic(t *testing.T) {
+	filters := map[string]interface{}{
+		"status":    map[string]interface{}{"not_in": []interface{}{"Done", "Closed"}},
+		"project":   "POS",
+		"cf[10020]": "Sprint 1",
+		"created":   map[string]interface{}{"lt": "-7d", "gte": "-30d"},
+		"assignee":  map[string]interface{}{"is": "EMPTY"},
+	}
+
+	want := `assignee is EMPTY AND cf[10020] = "Sprint 1" AND created >= "-30d" AND created < "-7d" AND project = "POS" AND status not in ("Done", "Closed")`
+	for i := 0; i < 20; i++ {
+		err, jql := getJql(filters)
+		require.NoError(t, err)
+		require.Equal(t, want, jql)
+	}
+}
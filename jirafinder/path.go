@@ -0,0 +1,165 @@
+package jirafinder
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// pathSegment is a step of a field path: a key of the current object followed
+// by selectors, -1 for [*] selecting all the elements of an array, n for [n]
+type pathSegment struct {
+	key       string
+	selectors []int
+}
+
+// isFieldPath tells if the field is a path expression such as assignee.emailAddress or fixVersions[*].name
+func isFieldPath(field string) bool {
+	return strings.ContainsAny(field, ".[")
+}
+
+// splitFieldPath separates the field starting the path from the rest of it
+func splitFieldPath(expr string) (string, string) {
+	if i := strings.IndexAny(expr, ".["); i >= 0 {
+		return expr[:i], expr[i:]
+	}
+
+	return expr, ""
+}
+
+// validateFieldPaths checks the selectors of the path expressions of the fields
+// to retrieve, the other expressions may be field names holding a dot
+func validateFieldPaths(fields []string) error {
+	for _, field := range fields {
+		if !strings.Contains(field, "[") {
+			continue
+		}
+
+		if err, _ := parseFieldPath(field); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseFieldPath(expr string) (error, []pathSegment) {
+	segments := make([]pathSegment, 0)
+
+	for _, part := range strings.Split(expr, ".") {
+		key := part
+		selectors := make([]int, 0)
+
+		if i := strings.IndexByte(part, '['); i >= 0 {
+			key = part[:i]
+
+			rest := part[i:]
+			for rest != "" {
+				end := strings.IndexByte(rest, ']')
+				if rest[0] != '[' || end < 0 {
+					return errors.Errorf("invalid field path '%s': malformed selector in '%s'", expr, part), nil
+				}
+
+				selector := strings.TrimSpace(rest[1:end])
+				if selector == "*" {
+					selectors = append(selectors, -1)
+				} else if n, err := strconv.Atoi(selector); err == nil && n >= 0 {
+					selectors = append(selectors, n)
+				} else {
+					return errors.Errorf("invalid field path '%s': selector [%s] is neither * nor an index", expr, selector), nil
+				}
+
+				rest = rest[end+1:]
+			}
+		}
+
+		if key == "" {
+			return errors.Errorf("invalid field path '%s': empty key", expr), nil
+		}
+
+		segments = append(segments, pathSegment{key: key, selectors: selectors})
+	}
+
+	return nil, segments
+}
+
+// evalFieldPath gives the values found at the path in the issue, the path
+// starts in the fields of the issue, or at its top level for key, id or changelog
+func evalFieldPath(issue map[string]interface{}, segments []pathSegment) []interface{} {
+	if len(segments) == 0 {
+		return nil
+	}
+
+	var root interface{} = issue
+	if fields, ok := issue["fields"].(map[string]interface{}); ok {
+		if _, ok := fields[segments[0].key]; ok {
+			root = fields
+		}
+	}
+
+	values := []interface{}{root}
+	for _, segment := range segments {
+		next := make([]interface{}, 0, len(values))
+
+		for _, v := range values {
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			child, ok := obj[segment.key]
+			if !ok {
+				continue
+			}
+
+			children := []interface{}{child}
+			for _, selector := range segment.selectors {
+				selected := make([]interface{}, 0)
+				for _, c := range children {
+					array, ok := c.([]interface{})
+					if !ok {
+						continue
+					}
+
+					if selector < 0 {
+						selected = append(selected, array...)
+					} else if selector < len(array) {
+						selected = append(selected, array[selector])
+					}
+				}
+				children = selected
+			}
+
+			next = append(next, children...)
+		}
+
+		values = next
+	}
+
+	return values
+}
+
+// getPathValues renders the values found at the path expression, N/A when there is none
+func getPathValues(issue map[string]interface{}, expr string) []string {
+	err, segments := parseFieldPath(expr)
+	if err != nil {
+		return []string{"N/A"}
+	}
+
+	// objects are rendered like the values of the field holding them
+	name := segments[len(segments)-1].key
+
+	values := make([]string, 0)
+	for _, v := range evalFieldPath(issue, segments) {
+		if v != nil {
+			values = append(values, getValues(v, name)...)
+		}
+	}
+
+	if len(values) == 0 {
+		return []string{"N/A"}
+	}
+
+	return values
+}
@@ -0,0 +1,124 @@
+package jirafinder
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gojira/ferry/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pathIssue = `{
+  "id": "10006",
+  "key": "POS-7",
+  "fields": {
+    "assignee": {"displayName": "User Name", "emailAddress": "user@example.com"},
+    "reporter": null,
+    "status": {"name": "In Progress", "statusCategory": {"key": "indeterminate", "name": "In Progress"}},
+    "fixVersions": [{"id": "10000", "name": "1.0"}, {"id": "10001", "name": "1.1"}],
+    "labels": ["backend", "urgent"],
+    "timetracking": {"originalEstimate": "1d", "timeSpentSeconds": 5400},
+    "customfield_10020": [{"id": 3, "name": "Sprint 2", "state": "active"}],
+    "customfield_10050": {"rank": {"lexo": "0|i0007"}},
+    "flagged": true
+  }
+}`
+
+func TestParseFieldPath(t *testing.T) {
+	r := require.New(t)
+
+	err, segments := parseFieldPath("fixVersions[*].name")
+	r.NoError(err)
+	r.Equal([]pathSegment{{key: "fixVersions", selectors: []int{-1}}, {key: "name", selectors: []int{}}}, segments)
+
+	err, segments = parseFieldPath("customfield_10020[0][*].name")
+	r.NoError(err)
+	r.Equal([]int{0, -1}, segments[0].selectors)
+
+	for _, invalid := range []string{"status..name", "labels[x]", "labels[*", "labels[*]x", ".name", "labels[-1]"} {
+		err, _ := parseFieldPath(invalid)
+		r.Error(err, "path %s should be invalid", invalid)
+	}
+}
+
+func TestGetPathValue(t *testing.T) {
+	var issue map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(pathIssue), &issue))
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"assignee.emailAddress", "user@example.com"},
+		{"status.statusCategory.name", "In Progress"},
+		{"fixVersions[*].name", "1.0, 1.1"},
+		{"fixVersions[1].name", "1.1"},
+		{"fixVersions[5].name", "N/A"},
+		{"labels[*]", "backend, urgent"},
+		{"timetracking.timeSpentSeconds", "5400"},
+		{"customfield_10020[*].state", "active"},
+		{"reporter.emailAddress", "N/A"},
+		{"assignee.accountId", "N/A"},
+		{"flagged.value", "N/A"},
+		{"status.statusCategory", "In Progress"},
+		{"timetracking.originalEstimate", "1d"},
+		{"customfield_10050.rank", `{"lexo":"0|i0007"}`},
+		{"key.name", "N/A"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, strings.Join(getPathValues(issue, tt.path), ", "))
+		})
+	}
+}
+
+func TestJiraFinder_ProcessFieldPaths(t *testing.T) {
+	r := require.New(t)
+	err, c := config.New("../example_config/sample_for_test.json")
+	r.NoError(err)
+
+	c.FieldsToRetrieve = config.NewFields("key", "Sprint[*].name", "story points", "fixVersions[*].name", "status.name")
+	err, f := NewJiraFinder(c)
+	r.NoError(err)
+
+	_, fields := f.processFields(testFields)
+	r.Equal([]string{"key", "customfield_10020[*].name", "customfield_10030", "fixVersions[*].name", "status.name"}, fields)
+
+	params := make(map[string]string)
+	f.setFields(params)
+	r.Equal("key,customfield_10020,customfield_10030,fixVersions,status", params["fields"])
+}
+
+func TestJiraFinder_ProcessDottedFieldName(t *testing.T) {
+	r := require.New(t)
+	err, c := config.New("../example_config/sample_for_test.json")
+	r.NoError(err)
+
+	c.FieldsToRetrieve = config.NewFields("key", "Dev. Estimate", "Dev. Estimate.value")
+	err, f := NewJiraFinder(c)
+	r.NoError(err)
+
+	fields := append([]map[string]interface{}{{"id": "customfield_10050", "name": "Dev. Estimate", "custom": true}}, testFields...)
+	_, keys := f.processFields(fields)
+	r.Equal([]string{"key", "customfield_10050", "Dev. Estimate.value"}, keys)
+
+	issue := JiraIssue{
+		Data:   map[string]interface{}{"key": "POS-7", "fields": map[string]interface{}{"customfield_10050": "M"}},
+		Fields: keys,
+	}
+	r.Equal([][]string{{"POS-7"}, {"M"}, {"N/A"}}, columnValues(issue))
+}
+
+func TestJiraFinder_InvalidFieldPath(t *testing.T) {
+	r := require.New(t)
+	err, c := config.New("../example_config/sample_for_test.json")
+	r.NoError(err)
+
+	c.FieldsToRetrieve = config.NewFields("fixVersions[first].name")
+	err, _ = NewJiraFinder(c)
+	r.Error(err)
+	r.Contains(err.Error(), "invalid field path 'fixVersions[first].name'")
+}
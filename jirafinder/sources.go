@@ -0,0 +1,253 @@
+package jirafinder
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Sprint selections of the Sprint filter when a Board is set, any other value is a sprint name or id
+const (
+	SprintActive = "active"
+	SprintNext   = "next"
+)
+
+// agilePage is a page of the Agile API, which pages with isLast instead of a total
+type agilePage struct {
+	StartAt int               `json:"startAt"`
+	IsLast  bool              `json:"isLast"`
+	Values  []json.RawMessage `json:"values"`
+}
+
+type board struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type sprint struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	State     string `json:"state"`
+	StartDate string `json:"startDate"`
+}
+
+// resolveSources resolves the sprint of the Board into the Sprint filter and
+// gives the query of the saved filter, empty when FilterID is not set
+func (f *JiraFinder) resolveSources() (error, string) {
+	if f.Config.Board != "" {
+		if err := f.resolveBoardSprint(); err != nil {
+			return err, ""
+		}
+	}
+
+	if f.Config.FilterID == 0 {
+		return nil, ""
+	}
+
+	return f.savedFilterJql(f.Config.FilterID)
+}
+
+// savedFilterJql gives the query of a filter saved in jira
+func (f *JiraFinder) savedFilterJql(id int) (error, string) {
+	body, err := f.get("/rest/api/2/filter/"+strconv.Itoa(id), nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to retrieve filter %d", id), ""
+	}
+
+	var filter struct {
+		Jql string `json:"jql"`
+	}
+	if err := json.Unmarshal(body, &filter); err != nil {
+		return errors.Wrapf(err, "failed to parse filter %d", id), ""
+	}
+
+	return nil, filter.Jql
+}
+
+// resolveBoardSprint replaces the Sprint filter, a selection such as active
+// or a sprint name, with the ids of the matching sprints of the Board
+func (f *JiraFinder) resolveBoardSprint() error {
+	err, boardID := f.resolveBoard(f.Config.Board)
+	if err != nil {
+		return err
+	}
+
+	// the filters may be shared with the caller, work on a copy
+	filters := make(map[string]interface{}, len(f.Config.Filters)+1)
+	key := "Sprint"
+	for k, v := range f.Config.Filters {
+		filters[k] = v
+		if strings.EqualFold(k, "sprint") {
+			key = k
+		}
+	}
+
+	var selections []interface{}
+	switch v := filters[key].(type) {
+	case nil:
+		selections = []interface{}{SprintActive}
+	case string:
+		selections = []interface{}{v}
+	case []interface{}:
+		selections = v
+	default:
+		return errors.Errorf("the Sprint filter of a board must be active, next, a sprint name or a list of them")
+	}
+
+	ids := make([]interface{}, 0, len(selections))
+	for _, s := range selections {
+		selection, ok := s.(string)
+		if !ok {
+			return errors.Errorf("the Sprint filter of a board must be active, next, a sprint name or a list of them")
+		}
+
+		err, sprints := f.resolveSprints(boardID, selection)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range sprints {
+			ids = append(ids, float64(id))
+		}
+	}
+
+	filters[key] = ids
+	f.Config.Filters = filters
+
+	return nil
+}
+
+// resolveBoard gives the id of the board, looking it up by name when it is not a number
+func (f *JiraFinder) resolveBoard(nameOrID string) (error, int) {
+	nameOrID = strings.TrimSpace(nameOrID)
+	if id, err := strconv.Atoi(nameOrID); err == nil {
+		return nil, id
+	}
+
+	err, values := f.getAgileValues("/rest/agile/1.0/board", map[string]string{"name": nameOrID})
+	if err != nil {
+		return errors.Wrapf(err, "failed to retrieve board '%s'", nameOrID), 0
+	}
+
+	// jira returns the boards containing the name, keep the exact matches
+	matches := make([]board, 0)
+	for _, v := range values {
+		var b board
+		if err := json.Unmarshal(v, &b); err != nil {
+			return errors.Wrapf(err, "failed to parse boards"), 0
+		}
+
+		if strings.EqualFold(b.Name, nameOrID) {
+			matches = append(matches, b)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return errors.Errorf("board '%s' not found", nameOrID), 0
+	case 1:
+		return nil, matches[0].ID
+	}
+
+	return errors.Errorf("several boards are named '%s', use the board id instead", nameOrID), 0
+}
+
+// resolveSprints gives the ids of the sprints of the board matching the
+// selection: the active ones, the next one to start, or the one with this name or id
+func (f *JiraFinder) resolveSprints(boardID int, selection string) (error, []int) {
+	selection = strings.TrimSpace(selection)
+
+	state := ""
+	switch strings.ToLower(selection) {
+	case SprintActive:
+		state = "active"
+	case SprintNext:
+		state = "future"
+	default:
+		if id, err := strconv.Atoi(selection); err == nil {
+			return nil, []int{id}
+		}
+	}
+
+	params := make(map[string]string)
+	if state != "" {
+		params["state"] = state
+	}
+
+	err, values := f.getAgileValues("/rest/agile/1.0/board/"+strconv.Itoa(boardID)+"/sprint", params)
+	if err != nil {
+		return errors.Wrapf(err, "failed to retrieve the sprints of board %d", boardID), nil
+	}
+
+	sprints := make([]sprint, 0, len(values))
+	for _, v := range values {
+		var s sprint
+		if err := json.Unmarshal(v, &s); err != nil {
+			return errors.Wrapf(err, "failed to parse sprints"), nil
+		}
+		sprints = append(sprints, s)
+	}
+
+	ids := make([]int, 0)
+	switch state {
+	case "active":
+		for _, s := range sprints {
+			ids = append(ids, s.ID)
+		}
+
+	case "future":
+		// sprints without start date are planned after the dated ones
+		var next *sprint
+		for i, s := range sprints {
+			if next == nil || s.StartDate != "" && (next.StartDate == "" || s.StartDate < next.StartDate) {
+				next = &sprints[i]
+			}
+		}
+		if next != nil {
+			ids = append(ids, next.ID)
+		}
+
+	default:
+		for _, s := range sprints {
+			if strings.EqualFold(s.Name, selection) {
+				ids = append(ids, s.ID)
+			}
+		}
+	}
+
+	if len(ids) == 0 {
+		return errors.Errorf("no sprint '%s' on board %d", selection, boardID), nil
+	}
+
+	return nil, ids
+}
+
+// getAgileValues gets the values of all the pages of an Agile API resource
+func (f *JiraFinder) getAgileValues(path string, params map[string]string) (error, []json.RawMessage) {
+	query := make(map[string]string, len(params)+1)
+	for k, v := range params {
+		query[k] = v
+	}
+
+	values := make([]json.RawMessage, 0)
+	for {
+		query["startAt"] = strconv.Itoa(len(values))
+
+		body, err := f.get(path, query)
+		if err != nil {
+			return err, nil
+		}
+
+		var page agilePage
+		if err := json.Unmarshal(body, &page); err != nil {
+			return errors.Wrapf(err, "failed to parse %s", path), nil
+		}
+
+		values = append(values, page.Values...)
+		if page.IsLast || len(page.Values) == 0 {
+			return nil, values
+		}
+	}
+}
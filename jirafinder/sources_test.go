@@ -0,0 +1,122 @@
+package jirafinder
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gojira/ferry/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStubFinder(t *testing.T) *JiraFinder {
+	err, f := NewJiraFinderFomFile("../example_config/sample_for_test.json")
+	require.NoError(t, err)
+	f.UseStub()
+
+	return f
+}
+
+func TestJiraFinder_SavedFilterJql(t *testing.T) {
+	r := require.New(t)
+	f := newStubFinder(t)
+
+	err, jql := f.savedFilterJql(10100)
+	r.NoError(err)
+	r.Equal("project = POS AND status != Done ORDER BY Rank ASC", jql)
+}
+
+func TestJiraFinder_ResolveBoard(t *testing.T) {
+	r := require.New(t)
+	f := newStubFinder(t)
+
+	err, id := f.resolveBoard("pos board")
+	r.NoError(err)
+	r.Equal(1, id, "only the exact name should match")
+
+	err, id = f.resolveBoard("42")
+	r.NoError(err)
+	r.Equal(42, id)
+
+	err, _ = f.resolveBoard("POS")
+	r.Error(err)
+	r.Contains(err.Error(), "board 'POS' not found")
+}
+
+func TestJiraFinder_ResolveSprints(t *testing.T) {
+	f := newStubFinder(t)
+
+	tests := []struct {
+		selection string
+		want      []int
+		err       string
+	}{
+		{selection: "active", want: []int{3}},
+		{selection: "Next", want: []int{4}},
+		{selection: "sprint 1", want: []int{2}},
+		{selection: "17", want: []int{17}},
+		{selection: "Sprint 9", err: "no sprint 'Sprint 9' on board 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selection, func(t *testing.T) {
+			err, ids := f.resolveSprints(1, tt.selection)
+			if tt.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ids)
+		})
+	}
+}
+
+func TestJiraFinder_ResolveBoardSprint(t *testing.T) {
+	r := require.New(t)
+	f := newStubFinder(t)
+
+	filters := map[string]interface{}{"Project": "POS", "sprint": []interface{}{"active", "Sprint 3"}}
+	f.Config.Filters = filters
+	f.Config.Board = "POS board"
+
+	r.NoError(f.resolveBoardSprint())
+	r.Equal([]interface{}{float64(3), float64(4)}, f.Config.Filters["sprint"])
+	r.Equal([]interface{}{"active", "Sprint 3"}, filters["sprint"], "the filters of the caller should be left as is")
+
+	// without sprint the active one is exported
+	f.Config.Filters = map[string]interface{}{"Project": "POS"}
+	r.NoError(f.resolveBoardSprint())
+	r.Equal([]interface{}{float64(3)}, f.Config.Filters["Sprint"])
+
+	f.Config.Filters = map[string]interface{}{"Sprint": map[string]interface{}{"ne": "Sprint 1"}}
+	r.Error(f.resolveBoardSprint())
+}
+
+func TestJiraFinder_SearchSources(t *testing.T) {
+	r := require.New(t)
+	err, c := config.New("../example_config/sample_for_test.json")
+	r.NoError(err)
+
+	dir, err := ioutil.TempDir("", "ferry")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+
+	c.DownloadPath = filepath.Join(dir, "issues.csv")
+	c.Filters = map[string]interface{}{"Sprint": "next"}
+	c.Board = "POS board"
+	c.FilterID = 10100
+
+	err, f := NewJiraFinder(c)
+	r.NoError(err)
+
+	jql, stop := useRecordingStub(f)
+	defer stop()
+
+	err = f.Search()
+	r.NoErrorf(err, "search func resulting to error: %s", err)
+	r.Equal("cf[10020] = 4 AND (project = POS AND status != Done) ORDER BY Rank ASC", jql.Load())
+}
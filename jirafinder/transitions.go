@@ -0,0 +1,94 @@
+package jirafinder
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gojira/ferry/config"
+	"github.com/pkg/errors"
+)
+
+// Export modes
+const (
+	// ModeIssues writes one row per issue with the fields to retrieve
+	ModeIssues = "issues"
+	// ModeTransitions writes one row per change of the changelog of the issues
+	ModeTransitions = "transitions"
+)
+
+// transitionsColumns are the columns of the transitions mode
+var transitionsColumns = []config.Field{
+	{Field: "key"},
+	{Field: "field"},
+	{Field: "from"},
+	{Field: "to"},
+	{Field: "author"},
+	{Field: "timestamp", Format: FormatDateTime, Layout: time.RFC3339},
+	{Field: "time since previous", Format: FormatNumber},
+}
+
+func validateMode(mode string) error {
+	switch strings.ToLower(mode) {
+	case "", ModeIssues, ModeTransitions:
+		return nil
+	}
+
+	return errors.Errorf("unknown mode '%s', expected issues or transitions", mode)
+}
+
+func (f *JiraFinder) mode() string {
+	if strings.ToLower(f.Config.Mode) == ModeTransitions {
+		return ModeTransitions
+	}
+
+	return ModeIssues
+}
+
+// columns gives the columns written by the mode, the event logs having the
+// fields to retrieve as attributes of their traces in both modes
+func (f *JiraFinder) columns() []config.Field {
+	if f.mode() == ModeTransitions && !isEventLogFormat(f.outputFormat()) {
+		return transitionsColumns
+	}
+
+	return f.Config.FieldsToRetrieve
+}
+
+// header gives the titles of the columns written by the mode
+func (f *JiraFinder) header() []string {
+	columns := f.columns()
+
+	header := make([]string, 0, len(columns))
+	for _, column := range columns {
+		header = append(header, column.Title())
+	}
+
+	return header
+}
+
+// transitionRows gives a row per change of the changelog of the issue, the
+// time since the previous change of the issue, or since its creation for the
+// first one, being in the unit of the flow
+func (f *JiraFinder) transitionRows(issue JiraIssue) [][]string {
+	key, _ := issue.Data["key"].(string)
+
+	since := issue.created
+	rows := make([][]string, 0)
+
+	for _, h := range issue.changelog {
+		timestamp := ""
+		if !h.Created.IsZero() {
+			timestamp = h.Created.Format(time.RFC3339)
+		}
+
+		// the items of a change are made at once, all of them since the previous change
+		elapsed := f.formatDuration(since, h.Created)
+		for _, item := range h.Items {
+			rows = append(rows, []string{key, item.Field, item.From, item.To, h.Author, timestamp, elapsed})
+		}
+
+		since = h.Created
+	}
+
+	return rows
+}
@@ -0,0 +1,117 @@
+package jirafinder
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gojira/ferry/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransitionRows(t *testing.T) {
+	r := require.New(t)
+
+	f := newFlowFinder(config.Flow{Unit: "hours"})
+	issue := flowIssue(nil)
+	issue["changelog"].(map[string]interface{})["histories"] = append(issue["changelog"].(map[string]interface{})["histories"].([]interface{}),
+		change("Lead", "2020-08-18T12:30:00.000+0200", item("assignee", "", "Ann"), item("Story Points", "3", "5")))
+
+	rows := f.transitionRows(JiraIssue{
+		Data:      map[string]interface{}{"key": "POS-7"},
+		changelog: parseChangelog(issue),
+		created:   parseJiraTime("2020-08-17T09:00:00.000+0000"),
+	})
+
+	r.Equal([][]string{
+		{"POS-7", "status", "To Do", "In Progress", "Ann", "2020-08-18T09:00:00Z", "24"},
+		{"POS-7", "assignee", "", "Ann", "Lead", "2020-08-18T12:30:00+02:00", "1.5"},
+		{"POS-7", "Story Points", "3", "5", "Lead", "2020-08-18T12:30:00+02:00", "1.5"},
+		{"POS-7", "status", "In Progress", "In Review", "Ann", "2020-08-19T09:00:00Z", "22.5"},
+		{"POS-7", "status", "In Review", "Done", "Bob", "2020-08-20T09:00:00Z", "24"},
+		{"POS-7", "status", "Done", "In Progress", "Bob", "2020-08-21T09:00:00Z", "24"},
+		{"POS-7", "status", "In Progress", "In Review", "Ann", "2020-08-21T21:00:00Z", "12"},
+	}, rows)
+}
+
+func TestJiraFinder_SearchTransitions(t *testing.T) {
+	r := require.New(t)
+	err, c := config.New("../example_config/sample_for_test.json")
+	r.NoError(err)
+
+	dir, err := ioutil.TempDir("", "ferry")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+
+	c.DownloadPath = filepath.Join(dir, "transitions.csv")
+	c.Mode = "Transitions"
+	err, f := NewJiraFinder(c)
+	r.NoError(err)
+	f.UseStub()
+
+	r.NoError(f.Search())
+
+	content, err := ioutil.ReadFile(c.DownloadPath)
+	r.NoError(err)
+
+	// every issue of the stub was moved to a sprint two and a half days after its creation
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	r.Len(lines, 7, "expected header and 6 rows")
+	r.Equal("key,field,from,to,author,timestamp,time since previous", lines[0])
+	r.Regexp(`^POS-\d+,Sprint,,POS Sprint 1,User Name,2020-08-19T20:11:37\+03:00,2\.5$`, lines[1])
+}
+
+func TestJiraFinder_SearchTransitionsSkipsSubTasks(t *testing.T) {
+	r := require.New(t)
+	err, c := config.New("../example_config/sample_for_test.json")
+	r.NoError(err)
+
+	dir, err := ioutil.TempDir("", "ferry")
+	r.NoError(err)
+	defer os.RemoveAll(dir)
+
+	c.DownloadPath = filepath.Join(dir, "transitions.csv")
+	c.Mode = ModeTransitions
+	err, f := NewJiraFinder(c)
+	r.NoError(err)
+	f.UseStub()
+
+	// count the issues fetched in front of the stub
+	var issues int32
+	stub := f.api.URL
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasPrefix(req.URL.Path, "/rest/api/2/issue/") {
+			atomic.AddInt32(&issues, 1)
+		}
+
+		resp, err := http.Get(stub + req.RequestURI)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		io.Copy(w, resp.Body)
+	}))
+	defer proxy.Close()
+	f.api.URL = proxy.URL
+
+	r.NoError(f.Search())
+	r.EqualValues(6, atomic.LoadInt32(&issues), "the sub tasks of the 6 issues are not fetched")
+}
+
+func TestJiraFinder_InvalidMode(t *testing.T) {
+	r := require.New(t)
+	err, c := config.New("../example_config/sample_for_test.json")
+	r.NoError(err)
+
+	c.Mode = "worklogs"
+	err, _ = NewJiraFinder(c)
+	r.Error(err)
+	r.Contains(err.Error(), "unknown mode 'worklogs'")
+}
@@ -1,229 +1,144 @@
 package jirafinder
 
 import (
-	"encoding/csv"
+	"encoding/json"
 	"fmt"
-	"github.com/pkg/errors"
-
-	"os"
+	"reflect"
 	"strconv"
 	"strings"
-	"time"
 )
 
-func writeToCsv(results [][]string, path string) error {
-	if len(results) == 0 {
-		fmt.Printf("No issues found to download")
-		return nil
-	}
-
-	file, err := os.Create(path)
-	if err != nil {
-		return errors.Wrapf(err, "failed to create file")
-	}
-
-	defer file.Close()
-
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
-
-	return errors.Wrapf(writer.WriteAll(results), "failed to write into to csv file")
+// GetFieldValue gets the field value based on the field name
+func getFieldValue(field string, issue JiraIssue) string {
+	return strings.Join(getFieldValues(field, issue), valueSeparator)
 }
 
-func getJql(filters map[string]string) string {
-	index := 0
-	totalCount := len(filters)
-	var b strings.Builder
-	for k, v := range filters {
-		index++
-		if strings.Contains(v, ",") {
-			valSlice := strings.Split(v, ",")
-			b.WriteString(k + " in (" + getInFilterValue(valSlice) + ")")
-		} else {
-			b.WriteString(k + "=" + "'" + v + "'")
-		}
-
-		if index != totalCount {
-			b.WriteString(" AND ")
-		}
-
+// getFieldValues gets the values of the field, one per element for the multi-value fields
+func getFieldValues(field string, issue JiraIssue) []string {
+	if field == "assignee" && issue.AssigneeName != "" {
+		return []string{issue.AssigneeName}
 	}
 
-	return b.String()
-}
-
-func getInFilterValue(values []string) string {
-	index := 0
-	totalCount := len(values)
-	var b strings.Builder
-	for _, val := range values {
-		index++
-		b.WriteString("'" + strings.TrimSpace(val) + "'")
-		if index != totalCount {
-			b.WriteString(",")
+	if strings.EqualFold(field, AttributionColumn) {
+		if issue.Attribution != "" {
+			return []string{issue.Attribution}
 		}
+		return []string{"N/A"}
 	}
 
-	return b.String()
-}
-
-// GetFieldValue gets the field value based on the field name
-func getFieldValue(field string, issue JiraIssue) string {
-	if field == "assignee" {
-		if issue.AssigneeName != "" {
-			return issue.AssigneeName
-		}
-		return getDevTaskAssigneeName(issue.SubTasks)
-	} else if field == "bug count" {
-		return fmt.Sprint(getNumberOfFunctionalBugs(issue.SubTasks))
-	} else if field == "complexity" {
-		return getComplexityBasedOnDevEstimation(issue.SubTasks)
+	if val, ok := issue.Derived[strings.ToLower(field)]; ok {
+		return []string{val}
 	}
 
-	return getValueFromField(issue.Data, field)
+	return getValuesFromField(issue.Data, field)
 }
 
 // GetValueFromField gets the value from the 'fields' property of the issue
 func getValueFromField(issue map[string]interface{}, field string) string {
+	return strings.Join(getValuesFromField(issue, field), valueSeparator)
+}
+
+// getValuesFromField gets the values from the 'fields' property of the issue
+func getValuesFromField(issue map[string]interface{}, field string) []string {
 	val, ok := issue["fields"]
 	if ok {
 		fieldsMap := val.(map[string]interface{})
 
 		val, ok := fieldsMap[field]
 		if ok {
-			if strings.ToLower(field) == "created" {
-				dateVal, _ := time.Parse("2006-01-02T15:04:05.999-0700", val.(string))
-				return dateVal.Format("02/Jan/06")
-			}
-			return strings.Replace(getValue(val, field), ",", "", -1)
+			return getValues(val, field)
 		}
 	}
-	return "N/A"
+	return []string{"N/A"}
 }
 
-// GetValue gets the value based on the type of interface
-func getValue(val interface{}, fieldName string) string {
-	var result string
+// getValues gets the values of a field, one per element for the arrays
+func getValues(val interface{}, fieldName string) []string {
 	arrayVal, isArray := val.([]interface{})
-	mapVal, isMap := val.(map[string]interface{})
-	if isArray {
-		result = arrayVal[0].(map[string]interface{})["value"].(string)
-	} else if isMap {
-		tmpResult, ok := mapVal[getNestedMapKeyName(fieldName)]
-		if ok {
-			result = tmpResult.(string)
-		}
-	} else if val != nil {
-		result = fmt.Sprint(val)
-	}
-
-	return result
-}
-
-// GetNestedMapKeyName gets the nested field name to search for a parent name
-func getNestedMapKeyName(fieldName string) string {
-	if strings.ToLower(fieldName) == "assignee" || strings.ToLower(fieldName) == "reporter" {
-		return "displayName"
+	if !isArray {
+		return []string{getValue(val, fieldName)}
 	}
 
-	if strings.ToLower(fieldName) == "issuetype" || strings.ToLower(fieldName) == "status" || strings.ToLower(fieldName) == "priority" {
-		return "name"
+	values := make([]string, 0, len(arrayVal))
+	for _, v := range arrayVal {
+		values = append(values, getValue(v, fieldName))
 	}
 
-	if strings.ToLower(fieldName) == "timetracking" {
-		return "originalEstimate"
-	}
-
-	return "value"
+	return values
 }
 
-// GetDevTaskAssigneeName gets Assignee name of the dev task, exclude code review task
-func getDevTaskAssigneeName(subTasks []SubTask) string {
-	for _, subTask := range subTasks {
-		if strings.Contains(subTask.Name, "Dev") && !strings.Contains(subTask.Name, "code review") {
-			return subTask.AssigneeName
-		}
+// GetValue gets the value based on the type of interface
+func getValue(val interface{}, fieldName string) string {
+	switch v := val.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []interface{}:
+		return strings.Join(getValues(v, fieldName), valueSeparator)
+	case map[string]interface{}:
+		return getObjectValue(v, fieldName)
 	}
 
-	return "N/A"
+	return fmt.Sprint(val)
 }
 
-// GetNumberOfFunctionalBugs gets the total number of functional issues in the sub tasks
-func getNumberOfFunctionalBugs(subTasks []SubTask) int {
-	numberOfFunctionalBugs := 0
-	for _, subTask := range subTasks {
-		if subTask.TaskType == "Functional Bug" {
-			numberOfFunctionalBugs++
+// getObjectValue gets the value displayed for an object: the name of a user,
+// the value of an option, the name of a version, a component or a sprint
+func getObjectValue(obj map[string]interface{}, fieldName string) string {
+	// options of cascading selects hold the selected child option
+	if child, ok := obj["child"].(map[string]interface{}); ok {
+		if val, ok := obj["value"]; ok {
+			return getValue(val, fieldName) + " - " + getObjectValue(child, fieldName)
 		}
 	}
-	return numberOfFunctionalBugs
-}
 
-// GetComplexityBasedOnDevEstimation gets the complexity based on dev estimation
-func getComplexityBasedOnDevEstimation(subTasks []SubTask) string {
-	totalHours := 0
-	for _, subTask := range subTasks {
-		if strings.Contains(subTask.Name, "Dev") && !strings.Contains(subTask.Name, "code review") {
-			hours, _ := strconv.Atoi(strings.TrimRight(subTask.TotalHours, "h"))
-			totalHours += hours
-		}
+	if val, ok := obj[getNestedMapKeyName(fieldName)]; ok {
+		return getValue(val, fieldName)
 	}
 
-	if totalHours <= 8 {
-		return "Extra Small"
-	} else if totalHours >= 9 && totalHours <= 16 {
-		return "Small"
-	} else if totalHours >= 17 && totalHours <= 24 {
-		return "Medium"
-	} else if totalHours >= 25 && totalHours <= 32 {
-		return "Large"
-	} else if totalHours >= 33 {
-		return "Complex"
+	for _, key := range []string{"value", "displayName", "name", "key", "id"} {
+		if val, ok := obj[key]; ok {
+			return getValue(val, fieldName)
+		}
 	}
-	return "N/A"
-}
 
-func isBug(issueType string) bool {
-	return strings.ToLower(issueType) == "bug" || strings.ToLower(issueType) == "functional bug" || strings.ToLower(issueType) == "production issue"
+	content, _ := json.Marshal(obj)
+	return string(content)
 }
 
-func getDeveloperNameFromLog(issue map[string]interface{}) string {
-	if issue == nil {
-		return ""
+// GetNestedMapKeyName gets the nested field name to search for a parent name
+func getNestedMapKeyName(fieldName string) string {
+	if strings.ToLower(fieldName) == "assignee" || strings.ToLower(fieldName) == "reporter" {
+		return "displayName"
 	}
-	developerName := ""
-	histories := issue["changelog"].(map[string]interface{})["histories"].([]interface{})
-	for _, history := range histories {
-		mapHistory := history.(map[string]interface{})
-		items := mapHistory["items"].([]interface{})
-		for _, item := range items {
-			strInDevelopment, ok := item.(map[string]interface{})["toString"].(string)
-			if ok && strInDevelopment == "In Development" {
-				developerName = mapHistory["author"].(map[string]interface{})["displayName"].(string)
-				break
-			}
-		}
 
-		if developerName != "" {
-			break
-		}
+	if strings.ToLower(fieldName) == "issuetype" || strings.ToLower(fieldName) == "status" || strings.ToLower(fieldName) == "priority" {
+		return "name"
 	}
 
-	return developerName
+	if strings.ToLower(fieldName) == "timetracking" {
+		return "originalEstimate"
+	}
 
+	return "value"
 }
 
-//HandleError handles errors
-func HandleError(err error) {
-	if err != nil {
-		panic(err.Error())
-	}
+// timeTrackingSeconds gives a duration of the time tracking of the issue, 0 when it is not tracked
+func timeTrackingSeconds(issue map[string]interface{}, key string) float64 {
+	fields, _ := issue["fields"].(map[string]interface{})
+	tracking, _ := fields["timetracking"].(map[string]interface{})
+	seconds, _ := tracking[key].(float64)
+
+	return seconds
 }
 
-func clean(filters map[string]string) {
+func clean(filters map[string]interface{}) {
 	for k1, v1 := range filters {
 		for k2, v2 := range filters {
-			if v1 == v2 && k1 != k2 {
+			if reflect.DeepEqual(v1, v2) && k1 != k2 {
 				if strings.HasPrefix(k1, "cf[") {
 					delete(filters, k1)
 				}
@@ -1,6 +1,7 @@
 package jirafinder
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -13,6 +14,16 @@ func (mc *MockHTTPRequest) Send() []byte {
 	return b
 }
 
+// legacyValues computes the columns derived when the config has no DerivedFields
+func legacyValues(t *testing.T, subTasks []SubTask) map[string]string {
+	err, fields := compileDerivedFields(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return deriveValues(fields, subTasks)
+}
+
 func TestGetNumberOfFunctionalBugs(t *testing.T) {
 	subTasks := make([]SubTask, 0)
 	subTask1 := SubTask{TaskType: "Functional Bug"}
@@ -20,10 +31,10 @@ func TestGetNumberOfFunctionalBugs(t *testing.T) {
 
 	subTasks = append(subTasks, subTask1, subTask2)
 
-	numberOfFunctionalBugs := getNumberOfFunctionalBugs(subTasks)
+	numberOfFunctionalBugs := legacyValues(t, subTasks)["bug count"]
 
-	if numberOfFunctionalBugs != 1 {
-		t.Errorf("The number of funcational bugs is incorrect, got : %d, want : %d", numberOfFunctionalBugs, 1)
+	if numberOfFunctionalBugs != "1" {
+		t.Errorf("The number of funcational bugs is incorrect, got : %s, want : %s", numberOfFunctionalBugs, "1")
 	}
 }
 
@@ -33,7 +44,7 @@ func TestGetDevTaskAssigneeNameReturnsDevName(t *testing.T) {
 
 	subTasks = append(subTasks, subTask1)
 
-	devTaskAssigneeName := getDevTaskAssigneeName(subTasks)
+	devTaskAssigneeName := legacyValues(t, subTasks)["assignee"]
 
 	if devTaskAssigneeName != "Dev1" {
 		t.Errorf("The dev task assignee name is wrong, got : %s, want : %s", devTaskAssigneeName, "Dev1")
@@ -47,7 +58,7 @@ func TestGetDevTaskAssigneeNameNotReturnsReviewerName(t *testing.T) {
 
 	subTasks = append(subTasks, subTask1, subTask2)
 
-	devTaskAssigneeName := getDevTaskAssigneeName(subTasks)
+	devTaskAssigneeName := legacyValues(t, subTasks)["assignee"]
 
 	if devTaskAssigneeName != "Dev2" {
 		t.Errorf("The dev task assignee name is wrong, got : %s, want : %s", devTaskAssigneeName, "Dev2")
@@ -61,7 +72,7 @@ func TestGetDevTaskAssigneeNameEmpty(t *testing.T) {
 
 	subTasks = append(subTasks, subTask1, subTask2)
 
-	devTaskAssigneeName := getDevTaskAssigneeName(subTasks)
+	devTaskAssigneeName := legacyValues(t, subTasks)["assignee"]
 
 	if devTaskAssigneeName != "N/A" {
 		t.Errorf("The dev task assignee name is wrong, got : %s, want : %s", devTaskAssigneeName, "N/A")
@@ -70,13 +81,13 @@ func TestGetDevTaskAssigneeNameEmpty(t *testing.T) {
 
 func TestComplexityBasedOnDevEstimates(t *testing.T) {
 	subTasks := make([]SubTask, 0)
-	subTask1 := SubTask{Name: "Dev : Analysis", TotalHours: "8h"}
-	subTask2 := SubTask{Name: "Dev : Coding", TotalHours: "12h"}
-	subTask3 := SubTask{Name: "Dev : UnitTesting", TotalHours: "12h"}
+	subTask1 := SubTask{Name: "Dev : Analysis", EstimateSeconds: 8 * 3600}
+	subTask2 := SubTask{Name: "Dev : Coding", EstimateSeconds: 12 * 3600}
+	subTask3 := SubTask{Name: "Dev : UnitTesting", EstimateSeconds: 12 * 3600}
 
 	subTasks = append(subTasks, subTask1, subTask2, subTask3)
 
-	complexity := getComplexityBasedOnDevEstimation(subTasks)
+	complexity := legacyValues(t, subTasks)["complexity"]
 
 	if complexity != "Large" {
 		t.Errorf("Complexity calculation is wrong. got : %s, want : %s", complexity, "Large")
@@ -85,14 +96,14 @@ func TestComplexityBasedOnDevEstimates(t *testing.T) {
 
 func TestComplexityBasedOnDevEstimatesNotIncludesQATask(t *testing.T) {
 	subTasks := make([]SubTask, 0)
-	subTask1 := SubTask{Name: "Dev : Analysis", TotalHours: "8h"}
-	subTask2 := SubTask{Name: "Dev : Coding", TotalHours: "12h"}
-	subTask3 := SubTask{Name: "Dev : UnitTesting", TotalHours: "12h"}
-	subTask4 := SubTask{Name: "QA : Testing", TotalHours: "12h"}
+	subTask1 := SubTask{Name: "Dev : Analysis", EstimateSeconds: 8 * 3600}
+	subTask2 := SubTask{Name: "Dev : Coding", EstimateSeconds: 12 * 3600}
+	subTask3 := SubTask{Name: "Dev : UnitTesting", EstimateSeconds: 12 * 3600}
+	subTask4 := SubTask{Name: "QA : Testing", EstimateSeconds: 12 * 3600}
 
 	subTasks = append(subTasks, subTask1, subTask2, subTask3, subTask4)
 
-	complexity := getComplexityBasedOnDevEstimation(subTasks)
+	complexity := legacyValues(t, subTasks)["complexity"]
 
 	if complexity != "Large" {
 		t.Errorf("Complexity calculation is wrong. got : %s, want : %s", complexity, "Large")
@@ -101,20 +112,38 @@ func TestComplexityBasedOnDevEstimatesNotIncludesQATask(t *testing.T) {
 
 func TestComplexityBasedOnDevEstimatesNotIncludesReviewTask(t *testing.T) {
 	subTasks := make([]SubTask, 0)
-	subTask1 := SubTask{Name: "Dev : Analysis", TotalHours: "8h"}
-	subTask2 := SubTask{Name: "Dev : Coding", TotalHours: "12h"}
-	subTask3 := SubTask{Name: "Dev : UnitTesting", TotalHours: "12h"}
-	subTask4 := SubTask{Name: "Dev : code review", TotalHours: "12h"}
+	subTask1 := SubTask{Name: "Dev : Analysis", EstimateSeconds: 8 * 3600}
+	subTask2 := SubTask{Name: "Dev : Coding", EstimateSeconds: 12 * 3600}
+	subTask3 := SubTask{Name: "Dev : UnitTesting", EstimateSeconds: 12 * 3600}
+	subTask4 := SubTask{Name: "Dev : code review", EstimateSeconds: 12 * 3600}
 
 	subTasks = append(subTasks, subTask1, subTask2, subTask3, subTask4)
 
-	complexity := getComplexityBasedOnDevEstimation(subTasks)
+	complexity := legacyValues(t, subTasks)["complexity"]
 
 	if complexity != "Large" {
 		t.Errorf("Complexity calculation is wrong. got : %s, want : %s", complexity, "Large")
 	}
 }
 
+// the complexity counts the estimates given in days or weeks, which the
+// former parsing of the "Xh" estimate counted as 0 hours
+func TestComplexityBasedOnEstimateSeconds(t *testing.T) {
+	subTaskIssue := map[string]interface{}{
+		"fields": map[string]interface{}{
+			"timetracking": map[string]interface{}{"originalEstimate": "2d", "originalEstimateSeconds": float64(57600)},
+		},
+	}
+
+	subTasks := []SubTask{{Name: "Dev : Coding", TotalHours: "2d", EstimateSeconds: timeTrackingSeconds(subTaskIssue, "originalEstimateSeconds")}}
+
+	complexity := legacyValues(t, subTasks)["complexity"]
+
+	if complexity != "Small" {
+		t.Errorf("Complexity calculation is wrong. got : %s, want : %s", complexity, "Small")
+	}
+}
+
 func TestGetFieldValueAssigneeFromIssue(t *testing.T) {
 	issue := JiraIssue{AssigneeName: "Dev1"}
 	fieldValue := getFieldValue("assignee", issue)
@@ -129,7 +158,7 @@ func TestGetFieldValueAssigneeFromSubTasks(t *testing.T) {
 	subTask1 := SubTask{AssigneeName: "Dev1", Name: "Dev : coding"}
 
 	subTasks = append(subTasks, subTask1)
-	issue := JiraIssue{SubTasks: subTasks}
+	issue := JiraIssue{SubTasks: subTasks, Derived: legacyValues(t, subTasks)}
 	fieldValue := getFieldValue("assignee", issue)
 
 	if fieldValue != "Dev1" {
@@ -235,3 +264,29 @@ func TestGetNestedMapKeyName(t *testing.T) {
 func ThrowError(t *testing.T, errorMsg string, expected string, actual string) {
 	t.Errorf("%s, got : %s, want: %s", errorMsg, actual, expected)
 }
+
+func TestGetValuesOfMultiValueFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		value interface{}
+		want  []string
+	}{
+		{"strings", "labels", []interface{}{"backend", "urgent"}, []string{"backend", "urgent"}},
+		{"options", "customfield_10030", []interface{}{map[string]interface{}{"value": "Web", "id": "1"}, map[string]interface{}{"value": "Mobile", "id": "2"}}, []string{"Web", "Mobile"}},
+		{"cascading option", "customfield_10031", map[string]interface{}{"value": "Europe", "child": map[string]interface{}{"value": "France"}}, []string{"Europe - France"}},
+		{"users", "customfield_10032", []interface{}{map[string]interface{}{"accountId": "1", "displayName": "Ann"}, map[string]interface{}{"accountId": "2", "displayName": "Bob"}}, []string{"Ann", "Bob"}},
+		{"versions", "fixVersions", []interface{}{map[string]interface{}{"id": "10000", "name": "1.0"}, map[string]interface{}{"id": "10001", "name": "1.1"}}, []string{"1.0", "1.1"}},
+		{"components", "components", []interface{}{map[string]interface{}{"id": "10000", "name": "API"}}, []string{"API"}},
+		{"numbers", "customfield_10033", []interface{}{float64(3), float64(0.5)}, []string{"3", "0.5"}},
+		{"empty", "labels", []interface{}{}, []string{}},
+		{"status", "status", map[string]interface{}{"name": "Done", "id": "3"}, []string{"Done"}},
+	}
+
+	for _, tt := range tests {
+		got := getValues(tt.value, tt.field)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: the values are wrong, got : %q, want : %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package jirafinder
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Supported output formats
+const (
+	FormatCSV    = "csv"
+	FormatTSV    = "tsv"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+// OutputWriter writes the exported issues as rows of a table
+type OutputWriter interface {
+	// WriteHeader writes the column names, it is called once before any row
+	WriteHeader(header []string) error
+	// WriteRow writes the values of one issue, in the same order as the header
+	WriteRow(row []string) error
+	// Close flushes any pending data and releases the destination
+	Close() error
+}
+
+// FormatFromPath infers the output format from the extension of the path, it returns an empty string when unknown
+func FormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV
+	case ".tsv", ".tab":
+		return FormatTSV
+	case ".json":
+		return FormatJSON
+	case ".ndjson", ".jsonl":
+		return FormatNDJSON
+	case ".xlsx":
+		return FormatXLSX
+	case ".xes":
+		return FormatXES
+	case ".jsonocel":
+		return FormatOCEL
+	}
+
+	return ""
+}
+
+// NewOutputWriter returns a writer for the given format writing to a temporary
+// file next to path. The file replaces path once committed after the writer is
+// closed, so that a failed export leaves the previous one untouched
+func NewOutputWriter(format string, path string) (error, OutputWriter, *OutputFile) {
+	if !isSupportedFormat(format) {
+		return errors.Errorf("unsupported output format '%s'", format), nil, nil
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrapf(err, "failed to create output directory"), nil, nil
+	}
+
+	file, err := ioutil.TempFile(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create file"), nil, nil
+	}
+
+	out := &OutputFile{File: file, path: path}
+	err, w := newWriter(format, out)
+	if err != nil {
+		out.Discard()
+		return err, nil, nil
+	}
+
+	return nil, w, out
+}
+
+// OutputFile is the temporary file of an export
+type OutputFile struct {
+	*os.File
+	path string
+}
+
+// Commit replaces the destination with the complete temporary file, once its writer is closed
+func (o *OutputFile) Commit() error {
+	if err := os.Chmod(o.Name(), 0644); err != nil {
+		o.Discard()
+		return errors.Wrapf(err, "failed to write %s", o.path)
+	}
+
+	if err := os.Rename(o.Name(), o.path); err != nil {
+		o.Discard()
+		return errors.Wrapf(err, "failed to write %s", o.path)
+	}
+
+	return nil
+}
+
+// Discard removes the temporary file, leaving the destination as it was
+func (o *OutputFile) Discard() {
+	o.File.Close()
+	os.Remove(o.Name())
+}
+
+func isSupportedFormat(format string) bool {
+	switch format {
+	case FormatCSV, FormatTSV, FormatJSON, FormatNDJSON, FormatXLSX, FormatXES, FormatOCEL:
+		return true
+	}
+
+	return false
+}
+
+func newWriter(format string, out io.WriteCloser) (error, OutputWriter) {
+	switch format {
+	case FormatCSV:
+		return nil, newDelimitedWriter(out, ',')
+	case FormatTSV:
+		return nil, newDelimitedWriter(out, '\t')
+	case FormatJSON:
+		return nil, &jsonWriter{out: out, buf: bufio.NewWriter(out)}
+	case FormatNDJSON:
+		return nil, &jsonWriter{out: out, buf: bufio.NewWriter(out), lines: true}
+	case FormatXLSX:
+		return nil, newXlsxWriter(out)
+	case FormatXES:
+		return nil, newXesWriter(out)
+	case FormatOCEL:
+		return nil, newOcelWriter(out)
+	}
+
+	return errors.Errorf("unsupported output format '%s'", format), nil
+}
+
+// delimitedWriter writes CSV and TSV files
+type delimitedWriter struct {
+	out    io.Closer
+	writer *csv.Writer
+}
+
+func newDelimitedWriter(out io.WriteCloser, comma rune) *delimitedWriter {
+	w := csv.NewWriter(out)
+	w.Comma = comma
+
+	return &delimitedWriter{out: out, writer: w}
+}
+
+func (w *delimitedWriter) WriteHeader(header []string) error {
+	return w.WriteRow(header)
+}
+
+func (w *delimitedWriter) WriteRow(row []string) error {
+	return errors.Wrapf(w.writer.Write(row), "failed to write row")
+}
+
+func (w *delimitedWriter) Close() error {
+	w.writer.Flush()
+	if err := w.writer.Error(); err != nil {
+		w.out.Close()
+		return errors.Wrapf(err, "failed to flush output")
+	}
+
+	return w.out.Close()
+}
+
+// jsonWriter writes every row as an object keyed by the header, either as a
+// single JSON array or as newline delimited JSON
+type jsonWriter struct {
+	out    io.Closer
+	buf    *bufio.Writer
+	header []string
+	lines  bool
+	rows   int
+}
+
+func (w *jsonWriter) WriteHeader(header []string) error {
+	w.header = header
+	if w.lines {
+		return nil
+	}
+
+	_, err := w.buf.WriteString("[")
+	return errors.Wrapf(err, "failed to write output")
+}
+
+func (w *jsonWriter) WriteRow(row []string) error {
+	var b strings.Builder
+
+	if !w.lines && w.rows > 0 {
+		b.WriteString(",")
+	}
+	if !w.lines {
+		b.WriteString("\n  ")
+	}
+
+	// build the object by hand so that keys keep the column order
+	b.WriteString("{")
+	for i, key := range w.header {
+		if i > 0 {
+			b.WriteString(",")
+		}
+
+		val := ""
+		if i < len(row) {
+			val = row[i]
+		}
+
+		k, _ := json.Marshal(key)
+		v, _ := json.Marshal(val)
+		b.Write(k)
+		b.WriteString(":")
+		b.Write(v)
+	}
+	b.WriteString("}")
+
+	if w.lines {
+		b.WriteString("\n")
+	}
+
+	w.rows++
+	_, err := w.buf.WriteString(b.String())
+	return errors.Wrapf(err, "failed to write output")
+}
+
+func (w *jsonWriter) Close() error {
+	if !w.lines {
+		end := "]\n"
+		if w.rows > 0 {
+			end = "\n]\n"
+		}
+		if _, err := w.buf.WriteString(end); err != nil {
+			w.out.Close()
+			return errors.Wrapf(err, "failed to write output")
+		}
+	}
+
+	if err := w.buf.Flush(); err != nil {
+		w.out.Close()
+		return errors.Wrapf(err, "failed to flush output")
+	}
+
+	return w.out.Close()
+}
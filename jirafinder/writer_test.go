@@ -0,0 +1,83 @@
+package jirafinder
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type bufferCloser struct {
+	bytes.Buffer
+}
+
+func (b *bufferCloser) Close() error {
+	return nil
+}
+
+func writeTable(t *testing.T, format string, rows ...[]string) string {
+	r := require.New(t)
+	out := &bufferCloser{}
+
+	err, w := newWriter(format, out)
+	r.NoError(err)
+	r.NoError(w.WriteHeader([]string{"key", "summary"}))
+	for _, row := range rows {
+		r.NoError(w.WriteRow(row))
+	}
+	r.NoError(w.Close())
+
+	return out.String()
+}
+
+func TestFormatFromPath(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal(FormatCSV, FormatFromPath("out/issues.CSV"))
+	r.Equal(FormatTSV, FormatFromPath("issues.tsv"))
+	r.Equal(FormatJSON, FormatFromPath("/tmp/issues.json"))
+	r.Equal(FormatNDJSON, FormatFromPath("issues.jsonl"))
+	r.Equal(FormatXES, FormatFromPath("issues.xes"))
+	r.Equal(FormatOCEL, FormatFromPath("issues.jsonocel"))
+	r.Equal("", FormatFromPath("issues.txt"))
+}
+
+func TestWriter_CSV(t *testing.T) {
+	out := writeTable(t, FormatCSV, []string{"POS-7", "Fix login, signup"})
+	require.Equal(t, "key,summary\nPOS-7,\"Fix login, signup\"\n", out)
+}
+
+func TestWriter_CSVMultiline(t *testing.T) {
+	out := writeTable(t, FormatCSV, []string{"POS-7", "Steps:\n1. \"login\""})
+	require.Equal(t, "key,summary\nPOS-7,\"Steps:\n1. \"\"login\"\"\"\n", out)
+}
+
+func TestWriter_TSVQuoting(t *testing.T) {
+	out := writeTable(t, FormatTSV, []string{"POS-7", "Fix\tlogin, signup"})
+	require.Equal(t, "key\tsummary\nPOS-7\t\"Fix\tlogin, signup\"\n", out)
+}
+
+func TestWriter_TSV(t *testing.T) {
+	out := writeTable(t, FormatTSV, []string{"POS-7", "Fix login"})
+	require.Equal(t, "key\tsummary\nPOS-7\tFix login\n", out)
+}
+
+func TestWriter_JSON(t *testing.T) {
+	r := require.New(t)
+
+	out := writeTable(t, FormatJSON, []string{"POS-7", "Fix \"login\""}, []string{"POS-8", "Reporting"})
+	r.Equal("[\n  {\"key\":\"POS-7\",\"summary\":\"Fix \\\"login\\\"\"},\n  {\"key\":\"POS-8\",\"summary\":\"Reporting\"}\n]\n", out)
+
+	r.Equal("[]\n", writeTable(t, FormatJSON))
+}
+
+func TestWriter_NDJSON(t *testing.T) {
+	out := writeTable(t, FormatNDJSON, []string{"POS-7", "Fix login"}, []string{"POS-8", "Reporting"})
+	require.Equal(t, "{\"key\":\"POS-7\",\"summary\":\"Fix login\"}\n{\"key\":\"POS-8\",\"summary\":\"Reporting\"}\n", out)
+}
+
+func TestWriter_UnsupportedFormat(t *testing.T) {
+	err, _, _ := NewOutputWriter("xml", "issues.xml")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unsupported output format")
+}
@@ -0,0 +1,378 @@
+package jirafinder
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gojira/ferry/config"
+	"github.com/pkg/errors"
+)
+
+// FormatXLSX is the Excel workbook output format
+const FormatXLSX = "xlsx"
+
+const defaultSheetName = "Issues"
+
+// cell styles declared in xlsxStyles
+const (
+	styleDefault = iota
+	styleDate
+	styleDateTime
+	styleHeader
+)
+
+// SheetWriter is implemented by writers able to split rows into named sheets
+type SheetWriter interface {
+	WriteSheetRow(sheet string, row []string) error
+}
+
+// ColumnWriter is implemented by writers typing their cells from the format of each column
+type ColumnWriter interface {
+	SetColumns(columns []config.Field)
+}
+
+var (
+	invalidSheetChars = strings.NewReplacer("[", "", "]", "", ":", "", "*", "", "?", "", "/", "", "\\", "")
+	excelEpoch        = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+)
+
+// xlsxWriter writes an Excel workbook with typed cells, a frozen header row
+// and an autofilter. Rows are spooled to temporary files per sheet and the
+// workbook is assembled when the writer is closed.
+type xlsxWriter struct {
+	out        io.WriteCloser
+	header     []string
+	columns    []config.Field
+	sheets     []*xlsxSheet
+	sheetNames map[string]*xlsxSheet
+}
+
+type xlsxSheet struct {
+	name string
+	file *os.File
+	buf  *bufio.Writer
+	rows int
+}
+
+func newXlsxWriter(out io.WriteCloser) *xlsxWriter {
+	return &xlsxWriter{
+		out:        out,
+		sheetNames: make(map[string]*xlsxSheet),
+	}
+}
+
+func (w *xlsxWriter) SetColumns(columns []config.Field) {
+	w.columns = columns
+}
+
+func (w *xlsxWriter) WriteHeader(header []string) error {
+	w.header = header
+	return nil
+}
+
+func (w *xlsxWriter) WriteRow(row []string) error {
+	return w.WriteSheetRow(defaultSheetName, row)
+}
+
+func (w *xlsxWriter) WriteSheetRow(sheet string, row []string) error {
+	err, s := w.sheet(sheet)
+	if err != nil {
+		return err
+	}
+
+	return s.writeRow(w.cells(row))
+}
+
+func (w *xlsxWriter) sheet(name string) (error, *xlsxSheet) {
+	name = sheetName(name)
+	if s, ok := w.sheetNames[strings.ToLower(name)]; ok {
+		return nil, s
+	}
+
+	file, err := ioutil.TempFile("", "ferry-sheet-*.xml")
+	if err != nil {
+		return errors.Wrapf(err, "failed to create temporary sheet"), nil
+	}
+
+	s := &xlsxSheet{name: name, file: file, buf: bufio.NewWriter(file)}
+	w.sheets = append(w.sheets, s)
+	w.sheetNames[strings.ToLower(name)] = s
+
+	header := make([]xlsxCell, len(w.header))
+	for i, h := range w.header {
+		header[i] = xlsxCell{text: h, style: styleHeader}
+	}
+
+	return s.writeRow(header), s
+}
+
+// sheetName makes name a valid Excel sheet name
+func sheetName(name string) string {
+	name = strings.TrimSpace(invalidSheetChars.Replace(name))
+	name = strings.Trim(name, "'")
+	if name == "" {
+		return defaultSheetName
+	}
+
+	if r := []rune(name); len(r) > 31 {
+		name = string(r[:31])
+	}
+
+	return name
+}
+
+type xlsxCell struct {
+	text   string
+	number string
+	serial float64
+	style  int
+}
+
+// cells types the values from the format of their column: number, hours and
+// days give numbers, date and datetime give dates, anything else is kept as
+// text, like the values which cannot be read in their format
+func (w *xlsxWriter) cells(row []string) []xlsxCell {
+	cells := make([]xlsxCell, len(row))
+	for i, val := range row {
+		cells[i] = xlsxCell{text: val}
+		if i >= len(w.columns) {
+			continue
+		}
+
+		column := columnFormat(w.columns[i])
+		switch strings.ToLower(column.Format) {
+		case FormatNumber, FormatHours, FormatDays:
+			if n, err := strconv.ParseFloat(strings.TrimSpace(val), 64); err == nil && !math.IsInf(n, 0) && !math.IsNaN(n) {
+				cells[i].number = strconv.FormatFloat(n, 'f', -1, 64)
+			}
+
+		case FormatDate, FormatDateTime:
+			layouts := jiraDateLayouts
+			if column.Layout != "" {
+				layouts = append([]string{column.Layout}, jiraDateLayouts...)
+			}
+
+			for _, layout := range layouts {
+				if t, err := time.Parse(layout, strings.TrimSpace(val)); err == nil {
+					cells[i].serial = excelSerial(t)
+					cells[i].style = styleDate
+					if strings.ToLower(column.Format) == FormatDateTime {
+						cells[i].style = styleDateTime
+					}
+					break
+				}
+			}
+		}
+	}
+
+	return cells
+}
+
+// excelSerial converts the wall clock of t to an Excel serial date
+func excelSerial(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return wall.Sub(excelEpoch).Hours() / 24
+}
+
+func (s *xlsxSheet) writeRow(cells []xlsxCell) error {
+	s.rows++
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<row r="%d">`, s.rows)
+	for i, c := range cells {
+		ref := cellRef(i, s.rows)
+
+		switch {
+		case c.number != "":
+			fmt.Fprintf(&b, `<c r="%s"><v>%s</v></c>`, ref, c.number)
+		case c.style == styleDate || c.style == styleDateTime:
+			fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, c.style, strconv.FormatFloat(c.serial, 'f', -1, 64))
+		case c.text != "":
+			fmt.Fprintf(&b, `<c r="%s" t="inlineStr"`, ref)
+			if c.style != styleDefault {
+				fmt.Fprintf(&b, ` s="%d"`, c.style)
+			}
+			b.WriteString(`><is><t xml:space="preserve">`)
+			xml.EscapeText(&b, []byte(c.text))
+			b.WriteString(`</t></is></c>`)
+		}
+	}
+	b.WriteString("</row>")
+
+	_, err := s.buf.WriteString(b.String())
+	return errors.Wrapf(err, "failed to write sheet %s", s.name)
+}
+
+// cellRef returns the A1 reference of the zero based column and one based row
+func cellRef(col int, row int) string {
+	return columnName(col) + strconv.Itoa(row)
+}
+
+func columnName(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+
+	return name
+}
+
+func (w *xlsxWriter) Close() error {
+	defer w.cleanup()
+
+	if len(w.sheets) == 0 {
+		if err, _ := w.sheet(defaultSheetName); err != nil {
+			w.out.Close()
+			return err
+		}
+	}
+
+	if err := w.writeWorkbook(); err != nil {
+		w.out.Close()
+		return errors.Wrapf(err, "failed to write xlsx file")
+	}
+
+	return w.out.Close()
+}
+
+func (w *xlsxWriter) cleanup() {
+	for _, s := range w.sheets {
+		s.file.Close()
+		os.Remove(s.file.Name())
+	}
+}
+
+func (w *xlsxWriter) writeWorkbook() error {
+	z := zip.NewWriter(w.out)
+
+	var sheets, rels, types, names strings.Builder
+	for i, s := range w.sheets {
+		fmt.Fprintf(&sheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlAttr(s.name), i+1, i+1)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+		fmt.Fprintf(&types, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+		if len(w.header) > 0 {
+			fmt.Fprintf(&names, `<definedName name="_xlnm._FilterDatabase" localSheetId="%d" hidden="1">'%s'!%s</definedName>`,
+				i, xmlAttr(strings.Replace(s.name, "'", "''", -1)), s.filterRange(len(w.header), true))
+		}
+	}
+	fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(w.sheets)+1)
+
+	definedNames := ""
+	if names.Len() > 0 {
+		definedNames = "<definedNames>" + names.String() + "</definedNames>"
+	}
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", fmt.Sprintf(xlsxContentTypes, types.String())},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, sheets.String(), definedNames)},
+		{"xl/_rels/workbook.xml.rels", fmt.Sprintf(xlsxWorkbookRels, rels.String())},
+		{"xl/styles.xml", xlsxStyles},
+	}
+
+	for _, p := range parts {
+		if err := writeZipPart(z, p.name, p.content); err != nil {
+			return err
+		}
+	}
+
+	for i, s := range w.sheets {
+		if err := s.copyTo(z, fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), len(w.header)); err != nil {
+			return err
+		}
+	}
+
+	return z.Close()
+}
+
+func writeZipPart(z *zip.Writer, name string, content string) error {
+	part, err := z.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(part, content)
+	return err
+}
+
+func (s *xlsxSheet) filterRange(columns int, absolute bool) string {
+	if columns == 0 {
+		columns = 1
+	}
+
+	if absolute {
+		return fmt.Sprintf("$A$1:$%s$%d", columnName(columns-1), s.rows)
+	}
+
+	return fmt.Sprintf("A1:%s%d", columnName(columns-1), s.rows)
+}
+
+// copyTo wraps the spooled rows of the sheet into a worksheet part
+func (s *xlsxSheet) copyTo(z *zip.Writer, name string, columns int) error {
+	if err := s.buf.Flush(); err != nil {
+		return err
+	}
+
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	part, err := z.Create(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(part, xlsxSheetStart); err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(part, s.file); err != nil {
+		return err
+	}
+
+	end := "</sheetData>"
+	if columns > 0 {
+		end += fmt.Sprintf(`<autoFilter ref="%s"/>`, s.filterRange(columns, false))
+	}
+	end += "</worksheet>"
+
+	_, err = io.WriteString(part, end)
+	return err
+}
+
+func xmlAttr(val string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(val))
+	return b.String()
+}
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>%s</Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>%s</sheets>%s</workbook>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">%s</Relationships>`
+
+// xlsxStyles declares the cell formats in the order of the style constants
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm"/></numFmts><fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts><fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills><borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders><cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs><cellXfs count="4"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="15" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs><cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles></styleSheet>`
+
+const xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews><sheetData>`
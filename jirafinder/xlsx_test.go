@@ -0,0 +1,116 @@
+package jirafinder
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/gojira/ferry/config"
+	"github.com/stretchr/testify/require"
+)
+
+func readXlsx(t *testing.T, data []byte) map[string]string {
+	r := require.New(t)
+
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	r.NoError(err)
+
+	parts := make(map[string]string)
+	for _, file := range z.File {
+		rc, err := file.Open()
+		r.NoError(err)
+		content, err := ioutil.ReadAll(rc)
+		r.NoError(err)
+		rc.Close()
+
+		// every part must be well formed xml
+		d := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			_, err := d.Token()
+			if err == io.EOF {
+				break
+			}
+			r.NoErrorf(err, "malformed part %s", file.Name)
+		}
+
+		parts[file.Name] = string(content)
+	}
+
+	return parts
+}
+
+func TestXlsxWriter_TypedCells(t *testing.T) {
+	r := require.New(t)
+	out := &bufferCloser{}
+
+	w := newXlsxWriter(out)
+	w.SetColumns([]config.Field{{Field: "key"}, {Field: "story points", Format: "number"}, {Field: "created"}, {Field: "summary"}})
+	r.NoError(w.WriteHeader([]string{"key", "story points", "created", "summary"}))
+	r.NoError(w.WriteRow([]string{"POS-7", "5", "17/Aug/20", "Fix <login> & signup"}))
+	r.NoError(w.WriteRow([]string{"POS-8", "N/A", "", "N/A"}))
+	r.NoError(w.Close())
+
+	parts := readXlsx(t, out.Bytes())
+	r.Contains(parts, "[Content_Types].xml")
+	r.Contains(parts, "xl/workbook.xml")
+	r.Contains(parts, "xl/styles.xml")
+
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	r.Contains(sheet, `state="frozen"`)
+	r.Contains(sheet, `<autoFilter ref="A1:D3"/>`)
+	r.Contains(sheet, `<c r="A1" t="inlineStr" s="3"><is><t xml:space="preserve">key</t></is></c>`)
+	r.Contains(sheet, `<c r="B2"><v>5</v></c>`)
+	r.Contains(sheet, `<c r="C2" s="1"><v>44060</v></c>`)
+	r.Contains(sheet, `Fix &lt;login&gt; &amp; signup`)
+	r.Contains(sheet, `<c r="B3" t="inlineStr"><is><t xml:space="preserve">N/A</t></is></c>`)
+	r.NotContains(sheet, `r="C3"`)
+}
+
+func TestXlsxWriter_TextLookingLikeNumbers(t *testing.T) {
+	r := require.New(t)
+	out := &bufferCloser{}
+
+	w := newXlsxWriter(out)
+	w.SetColumns([]config.Field{{Field: "fixVersions"}, {Field: "external id"}, {Field: "timespent", Format: "hours"}, {Field: "due", Format: "datetime", Layout: "02/01/2006 15:04"}})
+	r.NoError(w.WriteHeader([]string{"fixVersions", "external id", "timespent", "due"}))
+	r.NoError(w.WriteRow([]string{"1.10", "12345678901234567", "1.5", "17/08/2020 12:00"}))
+	r.NoError(w.Close())
+
+	sheet := readXlsx(t, out.Bytes())["xl/worksheets/sheet1.xml"]
+	r.Contains(sheet, `<c r="A2" t="inlineStr"><is><t xml:space="preserve">1.10</t></is></c>`)
+	r.Contains(sheet, `<c r="B2" t="inlineStr"><is><t xml:space="preserve">12345678901234567</t></is></c>`)
+	r.Contains(sheet, `<c r="C2"><v>1.5</v></c>`)
+	r.Contains(sheet, `<c r="D2" s="2"><v>44060.5</v></c>`)
+}
+
+func TestXlsxWriter_SheetPerIssueType(t *testing.T) {
+	r := require.New(t)
+	out := &bufferCloser{}
+
+	w := newXlsxWriter(out)
+	r.NoError(w.WriteHeader([]string{"key"}))
+	r.NoError(w.WriteSheetRow("Bug", []string{"POS-1"}))
+	r.NoError(w.WriteSheetRow("Story", []string{"POS-2"}))
+	r.NoError(w.WriteSheetRow("bug", []string{"POS-3"}))
+	r.NoError(w.WriteSheetRow("", []string{"POS-4"}))
+	r.NoError(w.Close())
+
+	parts := readXlsx(t, out.Bytes())
+	r.Contains(parts["xl/workbook.xml"], `<sheet name="Bug" sheetId="1" r:id="rId1"/><sheet name="Story" sheetId="2" r:id="rId2"/><sheet name="Issues" sheetId="3" r:id="rId3"/>`)
+	r.Contains(parts["xl/worksheets/sheet1.xml"], "POS-3")
+	r.Contains(parts["xl/worksheets/sheet2.xml"], `<autoFilter ref="A1:A2"/>`)
+	r.Contains(parts["xl/worksheets/sheet3.xml"], "POS-4")
+}
+
+func TestColumnName(t *testing.T) {
+	r := require.New(t)
+
+	r.Equal("A", columnName(0))
+	r.Equal("Z", columnName(25))
+	r.Equal("AA", columnName(26))
+	r.Equal("AZ", columnName(51))
+	r.Equal("BA", columnName(52))
+}
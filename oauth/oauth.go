@@ -0,0 +1,460 @@
+// Package oauth implements the OAuth 2.0 authorization code flow (3LO) of
+// Atlassian Cloud: the login through a local loopback redirect, the storage
+// of the refresh token and the renewal of the access tokens.
+package oauth
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gojira/ferry/config"
+	"github.com/pkg/errors"
+)
+
+// Atlassian endpoints used when the config does not override them
+const (
+	DefaultAuthURL      = "https://auth.atlassian.com/authorize"
+	DefaultTokenURL     = "https://auth.atlassian.com/oauth/token"
+	DefaultResourcesURL = "https://api.atlassian.com/oauth/token/accessible-resources"
+	DefaultAPIURL       = "https://api.atlassian.com/ex/jira/"
+	DefaultRedirectURL  = "http://localhost:8085/callback"
+)
+
+// DefaultScopes are requested when the config does not list any, offline_access grants the refresh token
+var DefaultScopes = []string{"read:jira-work", "read:jira-user", "offline_access"}
+
+var (
+	// loginTimeout bounds the time given to the user to approve the access
+	loginTimeout = 5 * time.Minute
+	// expirySkew renews access tokens a little before they actually expire
+	expirySkew = time.Minute
+
+	httpClient = &http.Client{Timeout: 30 * time.Second}
+)
+
+// Config holds the OAuth client registration and the endpoints to use
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	RedirectURL  string
+	AuthURL      string
+	TokenURL     string
+	ResourcesURL string
+	APIURL       string
+	TokenFile    string
+	// SiteURL selects the jira site among the ones granted, the first one is used when empty
+	SiteURL string
+}
+
+// Token is the state stored in the token file between two runs
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	Expiry       time.Time `json:"expiry"`
+	CloudID      string    `json:"cloud_id"`
+	SiteURL      string    `json:"site_url"`
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+type resource struct {
+	ID   string `json:"id"`
+	URL  string `json:"url"`
+	Name string `json:"name"`
+}
+
+// FromConfig builds the OAuth config from the Auth.OAuth section of the ferry config
+func FromConfig(c *config.Configuration) Config {
+	o := c.Auth.OAuth
+
+	return Config{
+		ClientID:     o.ClientID,
+		ClientSecret: o.ClientSecret,
+		Scopes:       o.Scopes,
+		RedirectURL:  o.RedirectURL,
+		AuthURL:      o.AuthURL,
+		TokenURL:     o.TokenURL,
+		ResourcesURL: o.ResourcesURL,
+		APIURL:       o.APIURL,
+		TokenFile:    o.TokenFile,
+		SiteURL:      c.JiraURL,
+	}.withDefaults()
+}
+
+func (c Config) withDefaults() Config {
+	if len(c.Scopes) == 0 {
+		c.Scopes = DefaultScopes
+	}
+	if c.RedirectURL == "" {
+		c.RedirectURL = DefaultRedirectURL
+	}
+	if c.AuthURL == "" {
+		c.AuthURL = DefaultAuthURL
+	}
+	if c.TokenURL == "" {
+		c.TokenURL = DefaultTokenURL
+	}
+	if c.ResourcesURL == "" {
+		c.ResourcesURL = DefaultResourcesURL
+	}
+	if c.APIURL == "" {
+		c.APIURL = DefaultAPIURL
+	}
+	if c.TokenFile == "" {
+		c.TokenFile = defaultTokenFile()
+	}
+
+	return c
+}
+
+func defaultTokenFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+
+	return filepath.Join(dir, "ferry", "oauth-token.json")
+}
+
+// Login runs the authorization code flow: it listens on the loopback redirect
+// URL, hands the authorization URL to open, waits for the user to approve the
+// access and stores the resulting token in the token file
+func Login(c Config, open func(authURL string) error) (error, *Token) {
+	c = c.withDefaults()
+
+	redirect, err := url.Parse(c.RedirectURL)
+	if err != nil {
+		return errors.Wrapf(err, "invalid redirect url"), nil
+	}
+
+	ln, err := net.Listen("tcp", redirect.Host)
+	if err != nil {
+		return errors.Wrapf(err, "failed to listen on %s", redirect.Host), nil
+	}
+
+	// a zero port picks a free one, the redirect url has to follow
+	if redirect.Port() == "0" {
+		_, port, _ := net.SplitHostPort(ln.Addr().String())
+		redirect.Host = net.JoinHostPort(redirect.Hostname(), port)
+	}
+
+	state, err := randomState()
+	if err != nil {
+		ln.Close()
+		return err, nil
+	}
+
+	codes := make(chan callbackResult, 1)
+	srv := &http.Server{Handler: callbackHandler(redirect.Path, state, codes)}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	if err := open(c.authorizeURL(state, redirect.String())); err != nil {
+		return errors.Wrapf(err, "failed to open the authorization url"), nil
+	}
+
+	var result callbackResult
+	select {
+	case result = <-codes:
+	case <-time.After(loginTimeout):
+		return errors.New("timed out waiting for the authorization"), nil
+	}
+
+	if result.err != nil {
+		return result.err, nil
+	}
+
+	err, token := exchange(c, map[string]string{
+		"grant_type":   "authorization_code",
+		"code":         result.code,
+		"redirect_uri": redirect.String(),
+	})
+	if err != nil {
+		return err, nil
+	}
+
+	err, site := c.site(token.AccessToken)
+	if err != nil {
+		return err, nil
+	}
+
+	token.CloudID = site.ID
+	token.SiteURL = site.URL
+
+	if err := SaveToken(c.TokenFile, token); err != nil {
+		return err, nil
+	}
+
+	return nil, token
+}
+
+func (c Config) authorizeURL(state string, redirectURL string) string {
+	params := url.Values{}
+	params.Set("audience", "api.atlassian.com")
+	params.Set("client_id", c.ClientID)
+	params.Set("scope", strings.Join(c.Scopes, " "))
+	params.Set("redirect_uri", redirectURL)
+	params.Set("state", state)
+	params.Set("response_type", "code")
+	params.Set("prompt", "consent")
+
+	return c.AuthURL + "?" + params.Encode()
+}
+
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrapf(err, "failed to generate state")
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+type callbackResult struct {
+	code string
+	err  error
+}
+
+// callbackHandler receives the redirect of the authorization server and
+// sends the authorization code, or the refusal, to results
+func callbackHandler(path string, state string, results chan<- callbackResult) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+
+		q := r.URL.Query()
+
+		var result callbackResult
+		switch {
+		case q.Get("state") != state:
+			result.err = errors.New("authorization state mismatch")
+		case q.Get("error") != "":
+			result.err = errors.Errorf("authorization refused: %s %s", q.Get("error"), q.Get("error_description"))
+		case q.Get("code") == "":
+			result.err = errors.New("authorization code missing")
+		default:
+			result.code = q.Get("code")
+		}
+
+		if result.err != nil {
+			http.Error(w, result.err.Error(), http.StatusBadRequest)
+		} else {
+			w.Write([]byte("ferry is authorized, you can close this window."))
+		}
+
+		select {
+		case results <- result:
+		default:
+		}
+	})
+}
+
+// exchange calls the token endpoint with the given grant
+func exchange(c Config, grant map[string]string) (error, *Token) {
+	grant["client_id"] = c.ClientID
+	grant["client_secret"] = c.ClientSecret
+
+	body, _ := json.Marshal(grant)
+	resp, err := httpClient.Post(c.TokenURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrapf(err, "failed to reach the token endpoint"), nil
+	}
+	defer resp.Body.Close()
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read the token response"), nil
+	}
+
+	var tr tokenResponse
+	if err := json.Unmarshal(content, &tr); err != nil && resp.StatusCode == http.StatusOK {
+		return errors.Wrapf(err, "failed to parse the token response"), nil
+	}
+
+	if resp.StatusCode != http.StatusOK || tr.AccessToken == "" {
+		msg := strings.TrimSpace(tr.Error + " " + tr.ErrorDescription)
+		if msg == "" {
+			msg = http.StatusText(resp.StatusCode)
+		}
+		return errors.Errorf("token request (%s) failed: %s", grant["grant_type"], msg), nil
+	}
+
+	return nil, &Token{
+		AccessToken:  tr.AccessToken,
+		RefreshToken: tr.RefreshToken,
+		Expiry:       time.Now().Add(time.Duration(tr.ExpiresIn) * time.Second),
+	}
+}
+
+// site finds the jira site granted to the access token
+func (c Config) site(accessToken string) (error, *resource) {
+	req, err := http.NewRequest("GET", c.ResourcesURL, nil)
+	if err != nil {
+		return errors.Wrapf(err, "invalid resources url"), nil
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the accessible resources"), nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("failed to list the accessible resources: %s", resp.Status), nil
+	}
+
+	var resources []resource
+	if err := json.NewDecoder(resp.Body).Decode(&resources); err != nil {
+		return errors.Wrapf(err, "failed to parse the accessible resources"), nil
+	}
+
+	for i, r := range resources {
+		if c.SiteURL == "" || sameSite(r.URL, c.SiteURL) {
+			return nil, &resources[i]
+		}
+	}
+
+	if c.SiteURL != "" {
+		return errors.Errorf("the access granted does not include %s", c.SiteURL), nil
+	}
+
+	return errors.New("the access granted does not include any jira site"), nil
+}
+
+func sameSite(a string, b string) bool {
+	return strings.EqualFold(strings.TrimRight(a, "/"), strings.TrimRight(b, "/"))
+}
+
+// LoadToken reads the token stored by Login
+func LoadToken(path string) (error, *Token) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read oauth token, run 'ferry auth login' first"), nil
+	}
+
+	var t Token
+	if err := json.Unmarshal(content, &t); err != nil {
+		return errors.Wrapf(err, "failed to parse oauth token file %s", path), nil
+	}
+
+	return nil, &t
+}
+
+// SaveToken stores the token in a file only readable by the current user
+func SaveToken(path string, t *Token) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return errors.Wrapf(err, "failed to create token directory")
+	}
+
+	content, _ := json.MarshalIndent(t, "", "  ")
+
+	// write then rename so that an interrupted run never leaves a truncated token
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, content, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write oauth token")
+	}
+
+	return errors.Wrapf(os.Rename(tmp, path), "failed to write oauth token")
+}
+
+// Authorizer sends the access token with every request and renews it with
+// the refresh token when it expires, it is safe for concurrent use
+type Authorizer struct {
+	config Config
+
+	mu    sync.Mutex
+	token *Token
+}
+
+// NewAuthorizer loads the token stored by Login
+func NewAuthorizer(c Config) (error, *Authorizer) {
+	c = c.withDefaults()
+
+	err, t := LoadToken(c.TokenFile)
+	if err != nil {
+		return err, nil
+	}
+
+	if t.RefreshToken == "" {
+		return errors.New("the oauth token has no refresh token, add the offline_access scope and run 'ferry auth login'"), nil
+	}
+
+	return nil, &Authorizer{config: c, token: t}
+}
+
+// APIURL gives the base url of the jira REST API of the authorized site
+func (a *Authorizer) APIURL() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return strings.TrimRight(a.config.APIURL, "/") + "/" + a.token.CloudID
+}
+
+// Authorize sets the bearer access token, renewing it first when it is about to expire
+func (a *Authorizer) Authorize(req *http.Request) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if time.Now().Add(expirySkew).After(a.token.Expiry) {
+		if err := a.refresh(); err != nil {
+			return err
+		}
+	}
+
+	req.Header.Set("Authorization", "Bearer "+a.token.AccessToken)
+	return nil
+}
+
+// Refresh renews the access token rejected by jira, unless another request already did
+func (a *Authorizer) Refresh(rejected string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if rejected != "Bearer "+a.token.AccessToken {
+		return nil
+	}
+
+	return a.refresh()
+}
+
+func (a *Authorizer) refresh() error {
+	err, t := exchange(a.config, map[string]string{
+		"grant_type":    "refresh_token",
+		"refresh_token": a.token.RefreshToken,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to refresh the oauth token, run 'ferry auth login' again")
+	}
+
+	// refresh tokens rotate, keep the previous one when none is returned
+	if t.RefreshToken == "" {
+		t.RefreshToken = a.token.RefreshToken
+	}
+	t.CloudID = a.token.CloudID
+	t.SiteURL = a.token.SiteURL
+	a.token = t
+
+	return SaveToken(a.config.TokenFile, t)
+}
@@ -0,0 +1,260 @@
+package oauth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gojira/ferry/httprequest"
+	"github.com/stretchr/testify/require"
+)
+
+// authServer is a stub of the Atlassian authorization server and API gateway
+type authServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	issued    int
+	refreshes int
+	deny      bool
+}
+
+func newAuthServer() *authServer {
+	s := &authServer{}
+	mux := http.NewServeMux()
+
+	// the user approves the access right away
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		redirect, _ := url.Parse(q.Get("redirect_uri"))
+		params := url.Values{"state": {q.Get("state")}}
+		if s.deny {
+			params.Set("error", "access_denied")
+		} else {
+			params.Set("code", "the-code")
+		}
+		redirect.RawQuery = params.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	})
+
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		var grant map[string]string
+		json.NewDecoder(r.Body).Decode(&grant)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		valid := grant["client_id"] == "client" && grant["client_secret"] == "secret"
+		switch grant["grant_type"] {
+		case "authorization_code":
+			valid = valid && grant["code"] == "the-code"
+		case "refresh_token":
+			valid = valid && grant["refresh_token"] == fmt.Sprintf("refresh-%d", s.issued)
+			s.refreshes++
+		default:
+			valid = false
+		}
+
+		if !valid {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":"invalid_grant","error_description":"Unknown or invalid refresh token."}`))
+			return
+		}
+
+		s.issued++
+		fmt.Fprintf(w, `{"access_token":"access-%d","refresh_token":"refresh-%d","expires_in":3600,"token_type":"Bearer"}`, s.issued, s.issued)
+	})
+
+	mux.HandleFunc("/oauth/token/accessible-resources", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":"cloud-1","url":"https://other.atlassian.net","name":"other"},{"id":"cloud-2","url":"https://team.atlassian.net","name":"team"}]`))
+	})
+
+	// the jira API only accepts the latest access token
+	mux.HandleFunc("/ex/jira/cloud-2/rest/api/2/field", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		current := fmt.Sprintf("Bearer access-%d", s.issued)
+		s.mu.Unlock()
+
+		if r.Header.Get("Authorization") != current {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`[]`))
+	})
+
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+func (s *authServer) config(tokenFile string) Config {
+	return Config{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "http://127.0.0.1:0/callback",
+		AuthURL:      s.URL + "/authorize",
+		TokenURL:     s.URL + "/oauth/token",
+		ResourcesURL: s.URL + "/oauth/token/accessible-resources",
+		APIURL:       s.URL + "/ex/jira/",
+		TokenFile:    tokenFile,
+		SiteURL:      "https://team.atlassian.net/",
+	}
+}
+
+// browse plays the browser of the user, following the redirect to the loopback callback
+func browse(authURL string) error {
+	go http.Get(authURL)
+	return nil
+}
+
+func tempTokenFile(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "ferry-oauth")
+	require.NoError(t, err)
+
+	return filepath.Join(dir, "ferry", "token.json"), func() { os.RemoveAll(dir) }
+}
+
+func TestLogin(t *testing.T) {
+	r := require.New(t)
+	s := newAuthServer()
+	defer s.Close()
+
+	tokenFile, cleanup := tempTokenFile(t)
+	defer cleanup()
+
+	err, token := Login(s.config(tokenFile), browse)
+	r.NoError(err)
+	r.Equal("access-1", token.AccessToken)
+	r.Equal("refresh-1", token.RefreshToken)
+	r.Equal("cloud-2", token.CloudID)
+	r.True(token.Expiry.After(time.Now().Add(50 * time.Minute)))
+
+	info, err := os.Stat(tokenFile)
+	r.NoError(err)
+	r.Equal(os.FileMode(0600), info.Mode().Perm())
+
+	err, stored := LoadToken(tokenFile)
+	r.NoError(err)
+	r.Equal(token.RefreshToken, stored.RefreshToken)
+	r.Equal("https://team.atlassian.net", stored.SiteURL)
+}
+
+func TestLoginDenied(t *testing.T) {
+	r := require.New(t)
+	s := newAuthServer()
+	s.deny = true
+	defer s.Close()
+
+	tokenFile, cleanup := tempTokenFile(t)
+	defer cleanup()
+
+	err, _ := Login(s.config(tokenFile), browse)
+	r.Error(err)
+	r.Contains(err.Error(), "access_denied")
+}
+
+func TestLoginStateMismatch(t *testing.T) {
+	r := require.New(t)
+	s := newAuthServer()
+	defer s.Close()
+
+	tokenFile, cleanup := tempTokenFile(t)
+	defer cleanup()
+
+	forge := func(authURL string) error {
+		u, _ := url.Parse(authURL)
+		go http.Get(u.Query().Get("redirect_uri") + "?code=stolen&state=forged")
+		return nil
+	}
+
+	err, _ := Login(s.config(tokenFile), forge)
+	r.Error(err)
+	r.Contains(err.Error(), "state mismatch")
+}
+
+func TestAuthorizer_RefreshesExpiredToken(t *testing.T) {
+	r := require.New(t)
+	s := newAuthServer()
+	defer s.Close()
+
+	tokenFile, cleanup := tempTokenFile(t)
+	defer cleanup()
+
+	c := s.config(tokenFile)
+	err, _ := Login(c, browse)
+	r.NoError(err)
+
+	// expire the stored token
+	_, token := LoadToken(tokenFile)
+	token.Expiry = time.Now().Add(-time.Minute)
+	r.NoError(SaveToken(tokenFile, token))
+
+	err, auth := NewAuthorizer(c)
+	r.NoError(err)
+	r.Equal(s.URL+"/ex/jira/cloud-2", auth.APIURL())
+
+	req, _ := http.NewRequest("GET", auth.APIURL()+"/rest/api/2/field", nil)
+	r.NoError(auth.Authorize(req))
+	r.Equal("Bearer access-2", req.Header.Get("Authorization"))
+
+	// the rotated refresh token is stored for the next run
+	_, stored := LoadToken(tokenFile)
+	r.Equal("refresh-2", stored.RefreshToken)
+	r.Equal("cloud-2", stored.CloudID)
+}
+
+func TestAuthorizer_RefreshOnUnauthorized(t *testing.T) {
+	r := require.New(t)
+	s := newAuthServer()
+	defer s.Close()
+
+	tokenFile, cleanup := tempTokenFile(t)
+	defer cleanup()
+
+	c := s.config(tokenFile)
+	err, _ := Login(c, browse)
+	r.NoError(err)
+
+	err, auth := NewAuthorizer(c)
+	r.NoError(err)
+
+	// the access token is revoked by the server mid-export
+	s.mu.Lock()
+	s.issued++
+	s.mu.Unlock()
+	auth.token.RefreshToken = "refresh-2"
+
+	api := httprequest.NewClient(auth.APIURL(), auth)
+	api.Retry = httprequest.RetryPolicy{MaxAttempts: 1}
+
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			_, err := api.Get("/rest/api/2/field", nil)
+			errs <- err
+		}()
+	}
+	for i := 0; i < 5; i++ {
+		r.NoError(<-errs)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r.Equal(1, s.refreshes, "concurrent rejections should refresh once")
+}
+
+func TestNewAuthorizer_NoLogin(t *testing.T) {
+	tokenFile, cleanup := tempTokenFile(t)
+	defer cleanup()
+
+	err, _ := NewAuthorizer(Config{TokenFile: tokenFile})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "run 'ferry auth login' first")
+}